@@ -0,0 +1,250 @@
+package avro
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Codec compresses and decompresses the blocks of object container files. Codecs must be safe for concurrent use.
+// Spec: https://avro.apache.org/docs/current/spec.html#Required+Codecs
+type Codec interface {
+	// Compress returns the compressed form of a serialized block.
+	Compress(block []byte) ([]byte, error)
+
+	// Decompress returns the serialized block from its compressed form.
+	Decompress(block []byte) ([]byte, error)
+}
+
+// CodecFactory creates a Codec which compresses at the given level, or DefaultCompressionLevel. Codecs without
+// levels ignore it.
+type CodecFactory func(level int) (Codec, error)
+
+// DefaultCompressionLevel selects the default level of a codec.
+const DefaultCompressionLevel = -1
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]CodecFactory{
+		"null":      func(int) (Codec, error) { return nullCodec{}, nil },
+		"deflate":   newDeflateCodec,
+		"snappy":    func(int) (Codec, error) { return snappyCodec{}, nil },
+		"zstandard": newZstandardCodec,
+		"bzip2":     func(int) (Codec, error) { return bzip2Codec{}, nil },
+		"xz":        newXZCodec,
+	}
+)
+
+// RegisterCodec makes a codec available under the name used for it in the avro.codec metadata of object container
+// files, replacing any codec registered under the same name.
+func RegisterCodec(name string, factory CodecFactory) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = factory
+}
+
+// Codecs returns the names of the registered codecs.
+func Codecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newCodec creates the codec registered under the given name. Files without a codec use the null codec.
+func newCodec(name string, level int) (Codec, error) {
+	if name == "" {
+		name = "null"
+	}
+	codecsMu.RLock()
+	factory := codecs[name]
+	codecsMu.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("unknown codec %s", name)
+	}
+	return factory(level)
+}
+
+// nullCodec leaves blocks uncompressed.
+type nullCodec struct{}
+
+func (nullCodec) Compress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+func (nullCodec) Decompress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+// deflateCodec compresses blocks with raw deflate (RFC 1951), without zlib headers or checksums.
+type deflateCodec struct {
+	level   int
+	writers sync.Pool
+}
+
+func newDeflateCodec(level int) (Codec, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return nil, fmt.Errorf("invalid deflate compression level %d", level)
+	}
+	return &deflateCodec{level: level}, nil
+}
+
+func (c *deflateCodec) Compress(block []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := c.writers.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(&buf, c.level); err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(block); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *deflateCodec) Decompress(block []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(block))
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// snappyCodec compresses blocks with snappy, followed by the big-endian CRC32 of the uncompressed block.
+type snappyCodec struct{}
+
+func (snappyCodec) Compress(block []byte) ([]byte, error) {
+	compressed := snappy.Encode(make([]byte, snappy.MaxEncodedLen(len(block))+crc32.Size), block)
+	return appendCRC32(compressed, block), nil
+}
+
+func (snappyCodec) Decompress(block []byte) ([]byte, error) {
+	if len(block) < crc32.Size {
+		return nil, ErrCorruptBlock
+	}
+	compressed, checksum := block[:len(block)-crc32.Size], block[len(block)-crc32.Size:]
+	decompressed, err := snappy.Decode(nil, compressed)
+	if err != nil || binary.BigEndian.Uint32(checksum) != crc32.ChecksumIEEE(decompressed) {
+		return nil, ErrCorruptBlock
+	}
+	return decompressed, nil
+}
+
+func appendCRC32(compressed, uncompressed []byte) []byte {
+	var checksum [crc32.Size]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(uncompressed))
+	return append(compressed, checksum[:]...)
+}
+
+// zstandardCodec compresses blocks into zstandard frames, at levels 1 (fastest) to 22 (best).
+type zstandardCodec struct {
+	level zstd.EncoderLevel
+
+	// the encoder is only created by the first Compress, readers never need one
+	encoderOnce sync.Once
+	encoder     *zstd.Encoder
+	encoderErr  error
+}
+
+var (
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+)
+
+func newZstandardCodec(level int) (Codec, error) {
+	encoderLevel := zstd.SpeedDefault
+	if level != DefaultCompressionLevel {
+		if level < 1 || level > 22 {
+			return nil, fmt.Errorf("invalid zstandard compression level %d", level)
+		}
+		encoderLevel = zstd.EncoderLevelFromZstd(level)
+	}
+	return &zstandardCodec{level: encoderLevel}, nil
+}
+
+func (c *zstandardCodec) Compress(block []byte) ([]byte, error) {
+	c.encoderOnce.Do(func() {
+		c.encoder, c.encoderErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(c.level))
+	})
+	if c.encoderErr != nil {
+		return nil, c.encoderErr
+	}
+	return c.encoder.EncodeAll(block, nil), nil
+}
+
+func (c *zstandardCodec) Decompress(block []byte) ([]byte, error) {
+	// the decoder is safe for concurrent use and shared by all files
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	if zstdDecoderErr != nil {
+		return nil, zstdDecoderErr
+	}
+	return zstdDecoder.DecodeAll(block, nil)
+}
+
+// bzip2Codec can only decompress blocks, the standard library has no bzip2 compressor.
+type bzip2Codec struct{}
+
+func (bzip2Codec) Compress(block []byte) ([]byte, error) {
+	return nil, errors.New("writing bzip2 is not supported")
+}
+
+func (bzip2Codec) Decompress(block []byte) ([]byte, error) {
+	return ioutil.ReadAll(bzip2.NewReader(bytes.NewReader(block)))
+}
+
+// xzCodec compresses blocks into xz streams. It has no compression levels.
+type xzCodec struct{}
+
+func newXZCodec(level int) (Codec, error) {
+	if level != DefaultCompressionLevel {
+		return nil, fmt.Errorf("invalid xz compression level %d", level)
+	}
+	return xzCodec{}, nil
+}
+
+func (xzCodec) Compress(block []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := xz.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(block); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (xzCodec) Decompress(block []byte) ([]byte, error) {
+	r, err := xz.NewReader(bytes.NewReader(block))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
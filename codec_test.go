@@ -0,0 +1,180 @@
+package avro
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"testing"
+)
+
+func writeTestDataFile(t *testing.T, n int, opts ...DataFileWriterOption) []byte {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i), StringField: "a string that compresses well"}), nil)
+		if i%100 == 99 {
+			assert(t, dfw.Flush(), nil)
+		}
+	}
+	assert(t, dfw.Close(), nil)
+	return buf.Bytes()
+}
+
+func readTestDataFile(t *testing.T, encoded []byte) []int64 {
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []int64
+	for dfr.HasNext() {
+		var p primitive
+		assert(t, dfr.Next(&p), nil)
+		values = append(values, p.LongField)
+	}
+	assert(t, dfr.Err(), nil)
+	return values
+}
+
+func TestDeflateCodec(t *testing.T) {
+	uncompressed := writeTestDataFile(t, 250)
+	compressed := writeTestDataFile(t, 250, WithCodec("deflate"))
+	fastest := writeTestDataFile(t, 250, WithCodec("deflate"), WithCompressionLevel(flate.BestSpeed))
+	assert(t, len(compressed) < len(uncompressed)/2, true)
+	assert(t, bytes.Contains(compressed, []byte(`avro.codec`+"\x0e"+`deflate`)), true)
+
+	for _, encoded := range [][]byte{uncompressed, compressed, fastest} {
+		values := readTestDataFile(t, encoded)
+		assert(t, len(values), 250)
+		assert(t, values[249], int64(249))
+	}
+
+	// decompression errors are reported with the block
+	header, err := ReadDataFileHeader(bytes.NewReader(compressed))
+	assert(t, err, nil)
+	corrupted := append([]byte(nil), compressed...)
+	blockStart := bytes.NewReader(corrupted[header.DataOffset:])
+	dec := NewBinaryDecoderReader(blockStart)
+	_, err = dec.ReadLong()
+	assert(t, err, nil)
+	_, err = dec.ReadLong()
+	assert(t, err, nil)
+	corrupted[len(corrupted)-blockStart.Len()] = 0xff // reserved block type
+	_, err = newDataFileReader(bytes.NewReader(corrupted), nil)
+	assert(t, err.Error(), "DataFileReader: Error decompressing block: flate: corrupt input before offset 1")
+
+	// blocks are raw deflate streams, as read by the Java and Python implementations
+	codec, err := newCodec("deflate", DefaultCompressionLevel)
+	assert(t, err, nil)
+	block, err := codec.Compress([]byte("block"))
+	assert(t, err, nil)
+	raw, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(block)))
+	assert(t, err, nil)
+	assert(t, string(raw), "block")
+	empty, err := codec.Compress(nil)
+	assert(t, err, nil)
+	assert(t, empty, []byte{3, 0})
+}
+
+type reverseCodec struct{}
+
+func (reverseCodec) reverse(block []byte) []byte {
+	reversed := make([]byte, len(block))
+	for i, b := range block {
+		reversed[len(block)-1-i] = b
+	}
+	return reversed
+}
+
+func (c reverseCodec) Compress(block []byte) ([]byte, error) {
+	return c.reverse(block), nil
+}
+
+func (c reverseCodec) Decompress(block []byte) ([]byte, error) {
+	return c.reverse(block), nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	_, err := NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), WithCodec("reverse"))
+	assert(t, err != nil, true)
+	_, err = NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), WithCodec("deflate"), WithCompressionLevel(10))
+	assert(t, err != nil, true)
+
+	RegisterCodec("reverse", func(int) (Codec, error) { return reverseCodec{}, nil })
+	defer func() {
+		codecsMu.Lock()
+		delete(codecs, "reverse")
+		codecsMu.Unlock()
+	}()
+	assert(t, Codecs(), []string{"bzip2", "deflate", "null", "reverse", "snappy", "xz", "zstandard"})
+
+	values := readTestDataFile(t, writeTestDataFile(t, 150, WithCodec("reverse")))
+	assert(t, len(values), 150)
+}
+
+func TestSnappyCodec(t *testing.T) {
+	compressed := writeTestDataFile(t, 250, WithCodec("snappy"))
+	assert(t, len(compressed) < len(writeTestDataFile(t, 250))/2, true)
+	values := readTestDataFile(t, compressed)
+	assert(t, len(values), 250)
+	assert(t, values[249], int64(249))
+
+	// the block is followed by the big-endian CRC32 of the uncompressed data
+	codec, err := newCodec("snappy", DefaultCompressionLevel)
+	assert(t, err, nil)
+	block, err := codec.Compress([]byte("block"))
+	assert(t, err, nil)
+	assert(t, block[len(block)-4:], []byte{0x83, 0x1b, 0x97, 0x22})
+
+	block[len(block)-1]++
+	_, err = codec.Decompress(block)
+	assert(t, err, ErrCorruptBlock)
+	_, err = codec.Decompress([]byte{1, 2})
+	assert(t, err, ErrCorruptBlock)
+
+	// a corrupt block stops reading the file
+	corrupted := append([]byte(nil), compressed...)
+	corrupted[len(corrupted)-40]++
+	dfr, err := newDataFileReader(bytes.NewReader(corrupted), nil)
+	assert(t, err, nil)
+	for dfr.HasNext() {
+		assert(t, dfr.Next(&primitive{}), nil)
+	}
+	assert(t, dfr.Err(), ErrCorruptBlock)
+}
+
+func TestZstandardAndXZCodecs(t *testing.T) {
+	uncompressed := len(writeTestDataFile(t, 250))
+	for _, opts := range [][]DataFileWriterOption{
+		{WithCodec("zstandard")},
+		{WithCodec("zstandard"), WithCompressionLevel(1)},
+		{WithCodec("zstandard"), WithCompressionLevel(22)},
+		{WithCodec("xz")},
+	} {
+		compressed := writeTestDataFile(t, 250, opts...)
+		assert(t, len(compressed) < uncompressed/2, true)
+		values := readTestDataFile(t, compressed)
+		assert(t, len(values), 250)
+		assert(t, values[249], int64(249))
+	}
+
+	// readers don't create an encoder
+	dfr, err := NewDataFileReaderFrom(bytes.NewReader(writeTestDataFile(t, 10, WithCodec("zstandard"))))
+	assert(t, err, nil)
+	for dfr.HasNext() {
+		assert(t, dfr.Next(&primitive{}), nil)
+	}
+	assert(t, dfr.codec.(*zstandardCodec).encoder == nil, true)
+
+	schema := MustParseSchema(primitiveSchemaRaw)
+	_, err = NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), WithCodec("zstandard"), WithCompressionLevel(23))
+	assert(t, err != nil, true)
+	_, err = NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), WithCodec("xz"), WithCompressionLevel(6))
+	assert(t, err.Error(), "invalid xz compression level 6")
+	_, err = NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), WithCodec("bzip2"))
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,351 @@
+package avro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IncompatibilityKind classifies the reason data written with one schema can't be read with another.
+type IncompatibilityKind string
+
+const (
+	// NameMismatch is reported when named types don't have the same unqualified name and the reader has no alias
+	// for the writer name.
+	NameMismatch IncompatibilityKind = "NAME_MISMATCH"
+
+	// FixedSizeMismatch is reported when the sizes of two fixed types differ.
+	FixedSizeMismatch IncompatibilityKind = "FIXED_SIZE_MISMATCH"
+
+	// MissingEnumSymbols is reported when the writer enum has symbols the reader enum doesn't have.
+	MissingEnumSymbols IncompatibilityKind = "MISSING_ENUM_SYMBOLS"
+
+	// ReaderFieldMissingDefaultValue is reported when a reader field has no usable default and isn't written.
+	ReaderFieldMissingDefaultValue IncompatibilityKind = "READER_FIELD_MISSING_DEFAULT_VALUE"
+
+	// TypeMismatch is reported when the writer type can neither be read as nor promoted to the reader type.
+	TypeMismatch IncompatibilityKind = "TYPE_MISMATCH"
+
+	// MissingUnionBranch is reported when a type written by the writer has no matching branch in the reader union.
+	MissingUnionBranch IncompatibilityKind = "MISSING_UNION_BRANCH"
+)
+
+// Incompatibility describes a single reason data written with the writer schema can't be read with the reader
+// schema.
+type Incompatibility struct {
+	Kind IncompatibilityKind
+
+	// Path is a JSON pointer to the incompatible part of the reader schema, e.g. "/fields/1/type/symbols".
+	// Branches of a writer union are addressed by their index in the writer union.
+	Path string
+
+	Message string
+
+	// Reader and Writer are the incompatible parts of the reader and writer schemas.
+	Reader Schema
+	Writer Schema
+
+	// Version is the index of the previous schema the incompatibility was found against by CheckCompatibilityLevel.
+	Version int
+}
+
+// Error implements the error interface.
+func (i *Incompatibility) Error() string {
+	path := i.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s at %s: %s", i.Kind, path, i.Message)
+}
+
+// CheckCompatibility checks whether data written with the writer schema can be read with the reader schema, as
+// described by the schema resolution rules of the spec. It returns every incompatibility found, or nil if the
+// schemas are compatible.
+// Spec: https://avro.apache.org/docs/current/spec.html#Schema+Resolution
+func CheckCompatibility(reader, writer Schema) []*Incompatibility {
+	checker := &compatibilityChecker{inProgress: make(map[[2]*RecordSchema]bool)}
+	checker.check(reader, writer, "")
+	return checker.incompatibilities
+}
+
+// CompatibilityLevel names a compatibility guarantee between versions of a schema. The names are the same as those
+// used by the Confluent Schema Registry.
+type CompatibilityLevel string
+
+const (
+	// Backward requires that data written with the previous version can be read with the new one.
+	Backward CompatibilityLevel = "BACKWARD"
+
+	// BackwardTransitive requires that data written with any previous version can be read with the new one.
+	BackwardTransitive CompatibilityLevel = "BACKWARD_TRANSITIVE"
+
+	// Forward requires that data written with the new version can be read with the previous one.
+	Forward CompatibilityLevel = "FORWARD"
+
+	// ForwardTransitive requires that data written with the new version can be read with any previous one.
+	ForwardTransitive CompatibilityLevel = "FORWARD_TRANSITIVE"
+
+	// Full requires both Backward and Forward compatibility.
+	Full CompatibilityLevel = "FULL"
+
+	// FullTransitive requires both BackwardTransitive and ForwardTransitive compatibility.
+	FullTransitive CompatibilityLevel = "FULL_TRANSITIVE"
+
+	// NoCompatibility doesn't check anything.
+	NoCompatibility CompatibilityLevel = "NONE"
+)
+
+// CheckCompatibilityLevel checks a new version of a schema against the previous versions, ordered from oldest to
+// latest. The non-transitive levels only check against the latest version. The Version of each incompatibility
+// returned is the index of the previous schema it was found against.
+func CheckCompatibilityLevel(level CompatibilityLevel, schema Schema, previous ...Schema) ([]*Incompatibility, error) {
+	var backward, forward, transitive bool
+	switch level {
+	case Backward:
+		backward = true
+	case BackwardTransitive:
+		backward, transitive = true, true
+	case Forward:
+		forward = true
+	case ForwardTransitive:
+		forward, transitive = true, true
+	case Full:
+		backward, forward = true, true
+	case FullTransitive:
+		backward, forward, transitive = true, true, true
+	case NoCompatibility:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown compatibility level: %q", level)
+	}
+
+	var result []*Incompatibility
+	for version := len(previous) - 1; version >= 0; version-- {
+		var found []*Incompatibility
+		if backward {
+			found = append(found, CheckCompatibility(schema, previous[version])...)
+		}
+		if forward {
+			found = append(found, CheckCompatibility(previous[version], schema)...)
+		}
+		for _, incompatibility := range found {
+			incompatibility.Version = version
+		}
+		result = append(result, found...)
+		if !transitive {
+			break
+		}
+	}
+	return result, nil
+}
+
+type compatibilityChecker struct {
+	incompatibilities []*Incompatibility
+	// pairs of records which are being checked, recursive records are compatible unless proven otherwise
+	inProgress map[[2]*RecordSchema]bool
+}
+
+func (c *compatibilityChecker) report(kind IncompatibilityKind, path string, reader, writer Schema, format string, args ...interface{}) {
+	c.incompatibilities = append(c.incompatibilities, &Incompatibility{
+		Kind:    kind,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+		Reader:  reader,
+		Writer:  writer,
+	})
+}
+
+// compatible checks a pair of schemas without reporting anything.
+func (c *compatibilityChecker) compatible(reader, writer Schema) bool {
+	probe := &compatibilityChecker{inProgress: c.inProgress}
+	probe.check(reader, writer, "")
+	return len(probe.incompatibilities) == 0
+}
+
+func (c *compatibilityChecker) check(reader, writer Schema, path string) {
+	reader, writer = resolvedSchema(reader), resolvedSchema(writer)
+
+	if writerUnion, ok := writer.(*UnionSchema); ok {
+		// every branch the writer may have written must be readable
+		for i, t := range writerUnion.Types {
+			c.check(reader, t, path+"/"+strconv.Itoa(i))
+		}
+		return
+	} else if readerUnion, ok := reader.(*UnionSchema); ok {
+		for _, t := range readerUnion.Types {
+			if c.compatible(t, writer) {
+				return
+			}
+		}
+		c.report(MissingUnionBranch, path, reader, writer, "reader union lacks a type compatible with %s", writer.GetName())
+		return
+	}
+
+	if logicalTypeOf(reader) == LogicalTypeDecimal && logicalTypeOf(writer) == LogicalTypeDecimal {
+		c.checkDecimal(reader, writer, path)
+	}
+
+	switch r := reader.(type) {
+	case *NullSchema, *BooleanSchema:
+		c.checkType(r, writer, path)
+	case *IntSchema:
+		c.checkType(r, writer, path)
+	case *LongSchema:
+		c.checkType(r, writer, path, Int)
+	case *FloatSchema:
+		c.checkType(r, writer, path, Int, Long)
+	case *DoubleSchema:
+		c.checkType(r, writer, path, Int, Long, Float)
+	case *BytesSchema:
+		c.checkType(r, writer, path, String)
+	case *StringSchema:
+		c.checkType(r, writer, path, Bytes)
+	case *ArraySchema:
+		if w, ok := writer.(*ArraySchema); ok {
+			c.check(r.Items, w.Items, path+"/items")
+		} else {
+			c.checkType(r, writer, path)
+		}
+	case *MapSchema:
+		if w, ok := writer.(*MapSchema); ok {
+			c.check(r.Values, w.Values, path+"/values")
+		} else {
+			c.checkType(r, writer, path)
+		}
+	case *FixedSchema:
+		if w, ok := writer.(*FixedSchema); ok {
+			c.checkName(r.Name, nil, w.Name, r, w, path)
+			if r.Size != w.Size {
+				c.report(FixedSizeMismatch, path+"/size", r, w, "expected size %d, found %d", r.Size, w.Size)
+			}
+		} else {
+			c.checkType(r, writer, path)
+		}
+	case *EnumSchema:
+		if w, ok := writer.(*EnumSchema); ok {
+			c.checkName(r.Name, r.Aliases, w.Name, r, w, path)
+			var missing []string
+			for _, symbol := range w.Symbols {
+				if _, err := r.Value(symbol); err != nil {
+					missing = append(missing, symbol)
+				}
+			}
+			if len(missing) > 0 {
+				c.report(MissingEnumSymbols, path+"/symbols", r, w, "reader enum lacks symbols %s",
+					strings.Join(missing, ", "))
+			}
+		} else {
+			c.checkType(r, writer, path)
+		}
+	case *RecordSchema:
+		if w, ok := writer.(*RecordSchema); ok {
+			c.checkRecord(r, w, path)
+		} else {
+			c.checkType(r, writer, path)
+		}
+	default:
+		c.checkType(r, writer, path)
+	}
+}
+
+// checkType reports a type mismatch unless the writer has the same type as the reader or one of the types which
+// can be promoted to it.
+func (c *compatibilityChecker) checkType(reader, writer Schema, path string, promotions ...int) {
+	if writer.Type() == reader.Type() {
+		return
+	}
+	for _, t := range promotions {
+		if writer.Type() == t {
+			return
+		}
+	}
+	c.report(TypeMismatch, path, reader, writer, "expected %s, found %s", reader.GetName(), writer.GetName())
+}
+
+// checkDecimal reports decimals whose values the reader can't hold: the unscaled values are read as they are, so
+// the scales must be the same, and the reader precision must be at least the writer precision.
+func (c *compatibilityChecker) checkDecimal(reader, writer Schema, path string) {
+	readerPrecision, readerScale := decimalOf(reader)
+	writerPrecision, writerScale := decimalOf(writer)
+	if readerScale != writerScale {
+		c.report(TypeMismatch, path+"/scale", reader, writer, "expected decimal scale %d, found %d",
+			readerScale, writerScale)
+	}
+	if readerPrecision < writerPrecision {
+		c.report(TypeMismatch, path+"/precision", reader, writer, "expected decimal precision %d, found %d",
+			readerPrecision, writerPrecision)
+	}
+}
+
+func (c *compatibilityChecker) checkName(name string, aliases []string, writerName string, reader, writer Schema, path string) {
+	writerName = unqualifiedName(writerName)
+	if unqualifiedName(name) == writerName {
+		return
+	}
+	for _, alias := range aliases {
+		if unqualifiedName(alias) == writerName {
+			return
+		}
+	}
+	c.report(NameMismatch, path+"/name", reader, writer, "expected %s, found %s", name, writerName)
+}
+
+func (c *compatibilityChecker) checkRecord(reader, writer *RecordSchema, path string) {
+	pair := [2]*RecordSchema{reader, writer}
+	if c.inProgress[pair] {
+		return
+	}
+	c.inProgress[pair] = true
+	defer delete(c.inProgress, pair)
+
+	c.checkName(reader.Name, reader.Aliases, writer.Name, reader, writer, path)
+	for i, readerField := range reader.Fields {
+		fieldPath := path + "/fields/" + strconv.Itoa(i)
+		if writerField := writerFieldFor(readerField, writer); writerField != nil {
+			c.check(readerField.Type, writerField.Type, fieldPath+"/type")
+		} else if !readerField.hasDefault() {
+			c.report(ReaderFieldMissingDefaultValue, fieldPath, readerField.Type, nil,
+				"field %s has no default value and is missing in the writer", readerField.Name)
+		} else if _, err := readerField.Type.Generic(readerField.Default); err != nil {
+			c.report(ReaderFieldMissingDefaultValue, fieldPath, readerField.Type, nil,
+				"field %s has no default value and is missing in the writer", readerField.Name)
+		}
+	}
+}
+
+// writerFieldFor finds the writer field a reader field is read from, by name or one of its aliases.
+func writerFieldFor(readerField *SchemaField, writer *RecordSchema) *SchemaField {
+	for _, writerField := range writer.Fields {
+		if writerField.Name == readerField.Name {
+			return writerField
+		}
+	}
+	for _, alias := range readerField.Aliases {
+		for _, writerField := range writer.Fields {
+			if writerField.Name == alias {
+				return writerField
+			}
+		}
+	}
+	return nil
+}
+
+// resolvedSchema returns the schema a reference or wrapper stands for.
+func resolvedSchema(schema Schema) Schema {
+	switch s := schema.(type) {
+	case *refSchema:
+		return resolvedSchema(s.Ref)
+	case *RecursiveSchema:
+		return s.Actual
+	case *preparedRecordSchema:
+		return &s.RecordSchema
+	}
+	return schema
+}
+
+func unqualifiedName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
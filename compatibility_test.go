@@ -0,0 +1,153 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func incompatibilityKinds(incompatibilities []*Incompatibility) map[string]IncompatibilityKind {
+	kinds := make(map[string]IncompatibilityKind)
+	for _, i := range incompatibilities {
+		kinds[i.Path] = i.Kind
+	}
+	return kinds
+}
+
+func TestCheckCompatibilityPrimitives(t *testing.T) {
+	for _, c := range []struct {
+		reader, writer string
+		compatible     bool
+	}{
+		{`"int"`, `"int"`, true},
+		{`"long"`, `"int"`, true},
+		{`"float"`, `"long"`, true},
+		{`"double"`, `"float"`, true},
+		{`"string"`, `"bytes"`, true},
+		{`"bytes"`, `"string"`, true},
+		{`"int"`, `"long"`, false},
+		{`"float"`, `"double"`, false},
+		{`"boolean"`, `"int"`, false},
+		{`{"type": "long", "logicalType": "timestamp-millis"}`, `"int"`, true},
+		{`["null", "long"]`, `"int"`, true},
+		{`["null", "string"]`, `"int"`, false},
+		{`"long"`, `["int", "long"]`, true},
+		{`"long"`, `["null", "long"]`, false},
+	} {
+		incompatibilities := CheckCompatibility(MustParseSchema(c.reader), MustParseSchema(c.writer))
+		if (len(incompatibilities) == 0) != c.compatible {
+			t.Errorf("reading %s as %s: expected compatible %v, found %v", c.writer, c.reader, c.compatible, incompatibilities)
+		}
+	}
+}
+
+func TestCheckCompatibilityReport(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "v1", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "PAID", "SHIPPED"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 16}},
+		{"name": "tags", "type": {"type": "map", "values": "string"}},
+		{"name": "amount", "type": ["null", "int", "double"]}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Order", "namespace": "v2", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "status", "type": {"type": "enum", "name": "State", "symbols": ["NEW", "PAID"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 32}},
+		{"name": "tags", "type": {"type": "map", "values": "boolean"}},
+		{"name": "amount", "type": ["null", "long"]},
+		{"name": "customer", "type": "string"},
+		{"name": "note", "type": ["null", "string"], "default": null},
+		{"name": "identifier", "type": "long", "aliases": ["id"]},
+		{"name": "comment", "type": ["null", "string"]},
+		{"name": "nothing", "type": "null"},
+		{"name": "empty", "type": "null", "default": null}
+	]}`)
+
+	incompatibilities := CheckCompatibility(reader, writer)
+	assert(t, incompatibilityKinds(incompatibilities), map[string]IncompatibilityKind{
+		"/fields/0/type":         TypeMismatch,
+		"/fields/1/type/name":    NameMismatch,
+		"/fields/1/type/symbols": MissingEnumSymbols,
+		"/fields/2/type/size":    FixedSizeMismatch,
+		"/fields/3/type/values":  TypeMismatch,
+		"/fields/4/type/2":       MissingUnionBranch,
+		"/fields/5":              ReaderFieldMissingDefaultValue,
+		"/fields/8":              ReaderFieldMissingDefaultValue,
+		"/fields/9":              ReaderFieldMissingDefaultValue,
+	})
+	assert(t, len(incompatibilities), 9)
+	assert(t, incompatibilities[2].Error(), "MISSING_ENUM_SYMBOLS at /fields/1/type/symbols: reader enum lacks symbols SHIPPED")
+	assert(t, len(CheckCompatibility(writer, writer)), 0)
+}
+
+func TestCheckCompatibilityDecimal(t *testing.T) {
+	decimal := func(precision, scale int) Schema {
+		return MustParseSchema(fmt.Sprintf(`{"type": "record", "name": "Price", "fields": [
+			{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": %d, "scale": %d}},
+			{"name": "fee", "type": ["null", {"type": "fixed", "name": "Fee", "size": 8, "logicalType": "decimal", "precision": %d, "scale": %d}]}
+		]}`, precision, scale, precision, scale))
+	}
+	assert(t, len(CheckCompatibility(decimal(9, 2), decimal(9, 2))), 0)
+	assert(t, len(CheckCompatibility(decimal(12, 2), decimal(9, 2))), 0)
+	assert(t, incompatibilityKinds(CheckCompatibility(decimal(6, 2), decimal(9, 2))), map[string]IncompatibilityKind{
+		"/fields/0/type/precision": TypeMismatch,
+		"/fields/1/type/1":         MissingUnionBranch,
+	})
+	incompatibilities := CheckCompatibility(decimal(9, 3), decimal(9, 2))
+	assert(t, incompatibilityKinds(incompatibilities), map[string]IncompatibilityKind{
+		"/fields/0/type/scale": TypeMismatch,
+		"/fields/1/type/1":     MissingUnionBranch,
+	})
+	assert(t, incompatibilities[0].Error(), "TYPE_MISMATCH at /fields/0/type/scale: expected decimal scale 3, found 2")
+}
+
+func TestCheckCompatibilityRecursive(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "long"},
+		{"name": "next", "type": ["null", "Node"]},
+		{"name": "label", "type": "string", "default": ""}
+	]}`)
+	assert(t, len(CheckCompatibility(reader, writer)), 0)
+	assert(t, incompatibilityKinds(CheckCompatibility(writer, reader)), map[string]IncompatibilityKind{
+		"/fields/0/type": TypeMismatch,
+	})
+}
+
+func TestCheckCompatibilityLevel(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "int", "default": 0}
+	]}`)
+	v3 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "age", "type": "int", "default": 0},
+		{"name": "email", "type": "string"}
+	]}`)
+
+	check := func(level CompatibilityLevel, schema Schema, previous ...Schema) int {
+		incompatibilities, err := CheckCompatibilityLevel(level, schema, previous...)
+		assert(t, err, nil)
+		return len(incompatibilities)
+	}
+
+	assert(t, check(Backward, v2, v1), 0)
+	assert(t, check(Forward, v2, v1), 0)
+	assert(t, check(Full, v2, v1), 0)
+	assert(t, check(Backward, v3, v1, v2), 1)
+	assert(t, check(Forward, v3, v1, v2), 1)
+	assert(t, check(FullTransitive, v3, v1, v2), 4)
+	assert(t, check(NoCompatibility, v3, v1, v2), 0)
+
+	incompatibilities, _ := CheckCompatibilityLevel(BackwardTransitive, v3, v1, v2)
+	assert(t, len(incompatibilities), 2)
+	assert(t, incompatibilities[0].Version, 1)
+	assert(t, incompatibilities[1].Version, 0)
+
+	_, err := CheckCompatibilityLevel("SOMETIMES", v3, v1)
+	assert(t, err != nil, true)
+}
@@ -3,13 +3,14 @@ package avro
 import (
 	"bufio"
 	"bytes"
-	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"math"
 	"os"
+	"strings"
 )
 
 // Support decoding the avro Object Container File format.
@@ -39,21 +40,22 @@ var magic = []byte{'O', 'b', 'j', containerMagicVersion}
 // More here: https://avro.apache.org/docs/current/spec.html#Object+Container+Files
 type DataFileReader struct {
 	r             io.Reader
+	in            *positionReader
+	seeker        io.ReadSeeker // nil unless the input can seek
+	blockStart    int64
 	sharedCopyBuf []byte
-	header        *objFileHeader
+	header        *DataFileHeader
+	schema        Schema
 	block         *DataBlock
 	dec           Decoder
 	datum         DatumReader
-	codec         fileCodec
+	codec         Codec
+	concurrent    *concurrentDecoder // set by DecodeConcurrently
+	onCorrupt     func(CorruptData)  // set by SkipCorruptBlocks
+	index         *BlockIndex        // set by SetBlockIndex
 	err           error
 }
 
-var codecs = map[string]fileCodec{
-	"":        nullCodec{},
-	"null":    nullCodec{},
-	"deflate": flateCodec{},
-}
-
 // The header for object container files
 type objFileHeader struct {
 	Magic []byte            `avro:"magic"`
@@ -61,11 +63,80 @@ type objFileHeader struct {
 	Sync  []byte            `avro:"sync"`
 }
 
+// readObjFileHeader reads the header, checking the magic first so other files aren't decoded as a header.
 func readObjFileHeader(dec Decoder) (*objFileHeader, error) {
-	reader := NewSpecificDatumReader().SetSchema(objHeaderSchema)
-	header := &objFileHeader{}
-	err := reader.Read(header, dec)
-	return header, err
+	header := &objFileHeader{
+		Magic: make([]byte, len(magic)),
+		Meta:  make(map[string][]byte),
+		Sync:  make([]byte, containerSyncSize),
+	}
+	if err := dec.ReadFixed(header.Magic); err != nil {
+		return nil, err
+	} else if !bytes.Equal(header.Magic, magic) {
+		return nil, ErrNotAvroFile
+	}
+	n, err := dec.ReadMapStart()
+	for ; err == nil && n != 0; n, err = dec.MapNext() {
+		for i := int64(0); i < n; i++ {
+			if key, err := dec.ReadString(); err != nil {
+				return nil, err
+			} else if header.Meta[key], err = dec.ReadBytes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.ReadFixed(header.Sync); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// DataFileHeader is the header of an object container file, as returned by ReadDataFileHeader.
+type DataFileHeader struct {
+	// Schema is the writer schema of the file.
+	Schema Schema
+
+	// Codec is the name of the codec blocks are compressed with, "null" if the file doesn't set one.
+	Codec string
+
+	// Sync is the sync marker following every block.
+	Sync []byte
+
+	// Meta contains all metadata, including avro.schema and avro.codec.
+	Meta map[string][]byte
+
+	// DataOffset is the number of bytes read from r, where the first block starts.
+	DataOffset int64
+}
+
+// ReadDataFileHeader reads only the header of an object container file, leaving r at the start of the first block.
+// The codec isn't checked, so files with codecs which aren't registered can be inspected too.
+func ReadDataFileHeader(r io.Reader) (*DataFileHeader, error) {
+	in := &positionReader{r: r}
+	header, err := readObjFileHeader(NewBinaryDecoderReader(in))
+	if err == ErrNotAvroFile {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("DataFileReader: Error reading header: %s", err.Error())
+	}
+	schema, err := ParseSchema(string(header.Meta[schemaKey]))
+	if err != nil {
+		return nil, err
+	}
+	codec := string(header.Meta[codecKey])
+	if codec == "" {
+		codec = "null"
+	}
+	return &DataFileHeader{
+		Schema:     schema,
+		Codec:      codec,
+		Sync:       header.Sync,
+		Meta:       header.Meta,
+		DataOffset: in.pos,
+	}, nil
 }
 
 // NewDataFileReader enables reading an object container file from the filesystem.
@@ -90,7 +161,7 @@ func NewDataFileReader(filename string, ignoreMe ...DatumReader) (*DataFileReade
 		return nil, err
 	}
 
-	reader, err := newDataFileReader(f)
+	reader, err := newDataFileReader(f, nil)
 	if err != nil {
 		// If there's any decoding issues, try not leaking a file handle.
 		f.Close()
@@ -99,42 +170,79 @@ func NewDataFileReader(filename string, ignoreMe ...DatumReader) (*DataFileReade
 
 }
 
-func newDataFileReader(input io.Reader) (reader *DataFileReader, err error) {
-	dec := NewBinaryDecoderReader(input) // Since dec doesn't buffer, we can share it.
+// NewDataFileReaderFrom creates a DataFileReader reading an object container file from r.
+// SeekToBlock and Sync need r to be an io.ReadSeeker or io.ReaderAt.
+func NewDataFileReaderFrom(r io.Reader) (*DataFileReader, error) {
+	return newDataFileReader(r, nil)
+}
+
+// NewDataFileReaderWithSchema creates a DataFileReader which resolves the datums of an object container file against
+// the given reader schema, like DatumProjector: fields added to the reader schema get their defaults and fields it
+// doesn't have are skipped. If the reader schema is nil, datums are read with the writer schema as they are.
+func NewDataFileReaderWithSchema(r io.Reader, readerSchema Schema) (*DataFileReader, error) {
+	return newDataFileReader(r, readerSchema)
+}
+
+func newDataFileReader(input io.Reader, readerSchema Schema) (reader *DataFileReader, err error) {
+	in := &positionReader{r: input}
 	reader = &DataFileReader{
 		sharedCopyBuf: make([]byte, 4096),
 		r:             input,
-		dec:           dec,
+		in:            in,
+		dec:           NewBinaryDecoderReader(in), // Since dec doesn't buffer, we can share it.
 	}
-
-	if reader.header, err = readObjFileHeader(dec); err != nil {
-		return nil, fmt.Errorf("DataFileReader: Error reading header: %s", err.Error())
+	switch r := input.(type) {
+	case io.ReadSeeker:
+		reader.seeker = r
+		if in.pos, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	case io.ReaderAt:
+		reader.seeker = io.NewSectionReader(r, 0, math.MaxInt64)
+		in.r = reader.seeker
 	}
 
-	if !bytes.Equal(reader.header.Magic, magic) {
-		return nil, ErrNotAvroFile // TODO: consider formatting error magic value in
+	if reader.header, err = ReadDataFileHeader(reader.in); err != nil {
+		return nil, err
 	}
-
-	schema, err := ParseSchema(string(reader.header.Meta[schemaKey]))
-	if err != nil {
+	reader.schema = reader.header.Schema
+	if readerSchema == nil {
+		reader.datum = NewDatumReader(reader.schema)
+	} else if reader.datum, err = NewDatumProjector(readerSchema, reader.schema); err != nil {
 		return nil, err
 	}
-	reader.datum = NewDatumReader(schema)
 
-	codecName := string(reader.header.Meta[codecKey])
-	if codec := codecs[codecName]; codec == nil {
-		return nil, fmt.Errorf("DataFileReader: Don't know how to decode codec %s", codecName)
-	} else {
-		reader.codec = codec
+	if reader.codec, err = newCodec(reader.header.Codec, DefaultCompressionLevel); err != nil {
+		return nil, fmt.Errorf("DataFileReader: Don't know how to decode codec %s", reader.header.Codec)
 	}
 
-	if err := reader.NextBlock(); err != nil {
+	// files without blocks, like those flushed before the first write, are empty
+	if err := reader.NextBlock(); err != nil && err != io.EOF {
 		return nil, err
 	}
 
 	return reader, nil
 }
 
+// Schema returns the writer schema from the file header.
+func (reader *DataFileReader) Schema() Schema {
+	return reader.schema
+}
+
+// GetMeta returns the value of a key in the metadata of the file header, or nil if the key is missing.
+func (reader *DataFileReader) GetMeta(key string) []byte {
+	return reader.header.Meta[key]
+}
+
+// Metadata returns a copy of the metadata in the file header, including the reserved avro.schema and avro.codec keys.
+func (reader *DataFileReader) Metadata() map[string][]byte {
+	meta := make(map[string][]byte, len(reader.header.Meta))
+	for key, value := range reader.header.Meta {
+		meta[key] = value
+	}
+	return meta
+}
+
 func (reader *DataFileReader) stop(err error) error {
 	reader.err = err
 	return err
@@ -157,10 +265,11 @@ func (reader *DataFileReader) Err() error {
 // then HasNext will be false, even if there might be more data
 // in the data file.
 //
-// It might be possible to recover from corrupted data by jumping
-// to the next block by using the NextBlock() but this is not
-// guaranteed.
+// To skip corrupt blocks instead, see SkipCorruptBlocks.
 func (reader *DataFileReader) HasNext() bool {
+	if reader.concurrent != nil {
+		return reader.advanceConcurrently()
+	}
 	if reader.err != nil || reader.block == nil {
 		return false
 	}
@@ -170,7 +279,9 @@ func (reader *DataFileReader) HasNext() bool {
 func (reader *DataFileReader) advance() bool {
 	if reader.block == nil {
 		return false
-	} else if reader.block.BlockRemaining == 0 {
+	}
+	// skip empty blocks, like the one this package writes at the end of files
+	for reader.block.BlockRemaining == 0 {
 		if err := reader.NextBlock(); err != nil {
 			return false
 		}
@@ -186,16 +297,33 @@ func (reader *DataFileReader) advance() bool {
 //
 // Will error with io.EOF if you're past the end, loop HasNext() to prevent.
 func (reader *DataFileReader) Next(v interface{}) error {
+	if reader.concurrent != nil {
+		return reader.nextConcurrently(v)
+	}
 	if !reader.advance() {
 		return reader.err
 	}
 
-	err := reader.datum.Read(v, reader.block.decoder)
-	if err != nil {
-		return err
+	for {
+		err := reader.datum.Read(v, reader.block.decoder)
+		if err == nil {
+			reader.block.BlockRemaining--
+			return nil
+		} else if reader.onCorrupt == nil {
+			return err
+		}
+		// the rest of the block is skipped
+		reader.onCorrupt(CorruptData{
+			Offset: reader.blockStart,
+			Bytes:  reader.in.pos + containerSyncSize - reader.blockStart,
+			Blocks: 1,
+			Err:    err,
+		})
+		reader.block.BlockRemaining = 0
+		if !reader.advance() {
+			return reader.err
+		}
 	}
-	reader.block.BlockRemaining--
-	return nil
 }
 
 // NextBlock tells this DataFileReader to skip current block and move to next one.
@@ -206,7 +334,14 @@ func (reader *DataFileReader) Next(v interface{}) error {
 // May return an error if the block is malformed or io.EOF if no more blocks
 // left to read.
 func (reader *DataFileReader) NextBlock() error {
-	if err := reader.actualNextBlock(); err != nil {
+	if reader.concurrent != nil {
+		return errDecodingConcurrently
+	}
+	err := reader.actualNextBlock()
+	if err != nil && err != io.EOF && reader.onCorrupt != nil {
+		err = reader.skipCorruptBlocks(err)
+	}
+	if err != nil {
 		return reader.stop(err)
 	} else {
 		return err
@@ -215,71 +350,267 @@ func (reader *DataFileReader) NextBlock() error {
 
 // actualNextBlock is separated so we don't need to put reader.stop on all error returns
 func (reader *DataFileReader) actualNextBlock() error {
+	blockCount, raw, err := reader.readRawBlock()
+	if err != nil {
+		return err
+	}
+
+	data, err := reader.decompress(raw)
+	if err != nil {
+		return err
+	}
+
+	block := &DataBlock{
+		decoder:        NewBinaryDecoder(data),
+		raw:            raw,
+		BlockRemaining: blockCount,
+		NumEntries:     blockCount,
+		BlockSize:      len(raw),
+	}
+	reader.block = block
+	reader.err = nil
+
+	return nil
+}
+
+// decompress returns the datums of a block read from the file. Errors other than ErrCorruptBlock are wrapped.
+func (reader *DataFileReader) decompress(raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		// empty blocks, e.g. at the end of files written by this package, aren't compressed
+		return raw, nil
+	}
+	data, err := reader.codec.Decompress(raw)
+	if err != nil && err != ErrCorruptBlock {
+		return nil, fmt.Errorf("DataFileReader: Error decompressing block: %v", err)
+	}
+	return data, err
+}
+
+// readRawBlock checks the sync marker of the current block and reads the next block, without decompressing it.
+func (reader *DataFileReader) readRawBlock() (int64, []byte, error) {
 	// Close out the current block
 	if block := reader.block; block != nil {
-		// Drain out what's remaining into dev/null.
-		// If we're at the end of a block, shouldn't actually copy anything.
-		_, err := io.CopyBuffer(ioutil.Discard, block.reader, reader.sharedCopyBuf)
-		if err != nil {
-			return err
+		if err := readSync(reader.in, reader.header.Sync, reader.sharedCopyBuf[:containerSyncSize]); err != nil {
+			if err != io.EOF && err != ErrUnexpectedEOF {
+				// the corrupt data starts where the sync marker should be
+				reader.blockStart = reader.in.pos - containerSyncSize
+			}
+			return 0, nil, err
 		}
+		reader.block = nil
+	}
 
-		block.runCloser()
+	reader.blockStart = reader.in.pos
+	blockCount, blockSize, err := readBlockHeader(reader.dec)
+	if err != nil {
+		return 0, nil, err
+	}
 
-		// Check the sync data at end of block is equal
-		syncBuffer := reader.sharedCopyBuf[:containerSyncSize]
-		_, err = io.ReadFull(reader.r, syncBuffer)
-		if err != nil {
-			return err
-		}
-		if !bytes.Equal(syncBuffer, reader.header.Sync) {
-			return fmt.Errorf("was expecting sync %v, got %v", reader.header.Sync, syncBuffer)
-		}
-		reader.block = nil
+	// The whole block is read, datums are decoded from memory.
+	data, err := readBlockData(reader.in, blockSize)
+	if err != nil {
+		return 0, nil, err
 	}
+	return blockCount, data, nil
+}
 
-	// Read counts for the new block
-	blockCount, err := reader.dec.ReadLong()
+// readBlockHeader reads the datum count and the size of a block. At the end of the input it returns io.EOF.
+func readBlockHeader(dec Decoder) (int64, int64, error) {
+	blockCount, err := dec.ReadLong()
 	if err != nil {
 		// This is the only time an "unexpected EOF" may actually be expected.
 		if err == ErrUnexpectedEOF {
 			err = io.EOF
 		}
-		return err
+		return 0, 0, err
 	}
 
-	blockSize, err := reader.dec.ReadLong()
+	if blockCount < 0 {
+		return 0, 0, fmt.Errorf("Block count invalid: %d", blockCount)
+	}
+
+	blockSize, err := dec.ReadLong()
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 
 	if blockSize > math.MaxInt32 || blockSize < 0 {
-		return fmt.Errorf("Block size invalid or too large: %d", blockSize)
+		return 0, 0, fmt.Errorf("Block size invalid or too large: %d", blockSize)
 	}
+	return blockCount, blockSize, nil
+}
 
-	// Pipeline step 1: io.LimitReader ensures we don't read past the end of the block.
-	r := io.LimitReader(reader.r, blockSize)
+// readSync checks the sync marker at the end of a block, reading it into buf.
+func readSync(r io.Reader, sync []byte, buf []byte) error {
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	if !bytes.Equal(buf, sync) {
+		return fmt.Errorf("was expecting sync %v, got %v", sync, buf)
+	}
+	return nil
+}
 
-	// Pipeline step 2: Buffer for performance on underlying file object.
-	// Normally, bufio.Reader would read too far, but LimitReader prevents it.
-	r = bufio.NewReader(r)
+// readBlockData reads a block of the given size. The buffer grows with the data actually read, so a corrupt size
+// doesn't allocate more memory than the input holds.
+func readBlockData(r io.Reader, size int64) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if _, err := io.CopyN(buf, r, size); err == io.EOF {
+		return nil, ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	// Pipeline step 3: Use any decoder given by the codec for this block
+// Tell returns the byte offset of the current block, which can be passed to SeekToBlock. At the end of the file it
+// returns the offset of the end.
+func (reader *DataFileReader) Tell() int64 {
+	return reader.blockStart
+}
 
-	r, closer := reader.codec.CodecReader(r)
+// SeekToBlock moves to the block starting at the given byte offset, as returned by Tell. Needs the input to be an
+// io.ReadSeeker or io.ReaderAt.
+func (reader *DataFileReader) SeekToBlock(offset int64) error {
+	if reader.concurrent != nil {
+		return errDecodingConcurrently
+	}
+	if reader.seeker == nil {
+		return errors.New("DataFileReader: input is not seekable")
+	}
+	if _, err := reader.seeker.Seek(offset, io.SeekStart); err != nil {
+		return reader.stop(err)
+	}
+	reader.in.pos = offset
+	reader.block = nil
+	if err := reader.NextBlock(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
 
-	block := &DataBlock{
-		reader:         r,
-		closer:         closer,
-		decoder:        NewBinaryDecoderReader(r),
-		BlockRemaining: blockCount,
-		NumEntries:     blockCount,
-		BlockSize:      int(blockSize),
+// Sync moves to the first block after the next sync marker at or after the given byte offset, or to the end of the
+// file if there is none. Together with PastSync this splits a file into byte ranges which can be read independently:
+//
+//	reader.Sync(start)
+//	for reader.HasNext() && !reader.PastSync(end) {
+//		reader.Next(&v)
+//	}
+//
+// Needs the input to be an io.ReadSeeker or io.ReaderAt.
+func (reader *DataFileReader) Sync(position int64) error {
+	if reader.concurrent != nil {
+		return errDecodingConcurrently
 	}
-	reader.block = block
-	reader.err = nil
+	if reader.seeker == nil {
+		return errors.New("DataFileReader: input is not seekable")
+	}
+	if _, err := reader.seeker.Seek(position, io.SeekStart); err != nil {
+		return reader.stop(err)
+	}
+	if offset, err := findSync(bufio.NewReader(reader.seeker), position, reader.header.Sync); err == io.EOF {
+		// no more blocks
+		reader.blockStart, reader.block = offset, nil
+		reader.stop(io.EOF)
+		return nil
+	} else if err != nil {
+		return reader.stop(err)
+	} else {
+		return reader.SeekToBlock(offset)
+	}
+}
 
-	return nil
+// findSync returns the offset following the next sync marker, starting from the given offset. At the end of the input
+// it returns the offset of the end and io.EOF.
+func findSync(r io.ByteReader, offset int64, sync []byte) (int64, error) {
+	window := make([]byte, 0, 2*containerSyncSize)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return offset, err
+		}
+		offset++
+		if len(window) == cap(window) {
+			window = append(window[:0], window[containerSyncSize+1:]...)
+		}
+		window = append(window, b)
+		if len(window) >= containerSyncSize && bytes.Equal(window[len(window)-containerSyncSize:], sync) {
+			return offset, nil
+		}
+	}
+}
+
+// CorruptData describes data skipped by a DataFileReader after SkipCorruptBlocks.
+type CorruptData struct {
+	// Offset is the byte offset where the corrupt data starts.
+	Offset int64
+
+	// Bytes is the number of bytes skipped, up to the next valid block or the end of the file.
+	Bytes int64
+
+	// Blocks is the number of corrupt blocks skipped.
+	Blocks int
+
+	// Err is the error of the first corrupt block.
+	Err error
+}
+
+// SkipCorruptBlocks makes the reader recover from corrupt data, like partially overwritten files, instead of stopping
+// at it. When a block can't be read, the reader scans forward for the next sync marker followed by a valid block and
+// carries on from there. When a datum can't be decoded, the rest of its block is skipped. Each time, report is called
+// with the data skipped. A nil report stops recovering.
+//
+// Without an io.ReadSeeker or io.ReaderAt as input, the bytes of a corrupt block already read aren't scanned. It has
+// no effect on DecodeConcurrently.
+func (reader *DataFileReader) SkipCorruptBlocks(report func(CorruptData)) {
+	reader.onCorrupt = report
+}
+
+// skipCorruptBlocks scans forward from a corrupt block until it reads a valid one or reaches the end of the file.
+func (reader *DataFileReader) skipCorruptBlocks(cause error) error {
+	skipped := CorruptData{Offset: reader.blockStart, Err: cause}
+	for {
+		skipped.Blocks++
+		offset, err := reader.skipToSync(reader.blockStart)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		reader.blockStart, reader.block = offset, nil
+		if err == nil {
+			err = reader.actualNextBlock()
+		}
+		if err == nil || err == io.EOF {
+			skipped.Bytes = reader.blockStart - skipped.Offset
+			reader.onCorrupt(skipped)
+			return err
+		}
+		// the next block is corrupt too
+	}
+}
+
+// skipToSync moves the input past the next sync marker after the given offset.
+func (reader *DataFileReader) skipToSync(position int64) (int64, error) {
+	if reader.seeker == nil {
+		return findSync(byteReader{reader.in}, reader.in.pos, reader.header.Sync)
+	}
+	if _, err := reader.seeker.Seek(position, io.SeekStart); err != nil {
+		return 0, err
+	}
+	offset, err := findSync(bufio.NewReader(reader.seeker), position, reader.header.Sync)
+	if err != nil {
+		return offset, err
+	}
+	if _, err := reader.seeker.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	reader.in.pos = offset
+	return offset, nil
+}
+
+// PastSync returns true once the current block starts after the next sync marker at or after the given byte offset,
+// or the end of the file is reached.
+func (reader *DataFileReader) PastSync(position int64) bool {
+	return reader.blockStart >= position+containerSyncSize || reader.err == io.EOF
 }
 
 // Close the underlying file if necessary.
@@ -287,8 +618,8 @@ func (reader *DataFileReader) actualNextBlock() error {
 // Needed with filesystem files if you want to not leak filehandles.
 // Returns any error in closing.
 func (reader *DataFileReader) Close() error {
-	if block := reader.block; block != nil {
-		block.runCloser()
+	if reader.concurrent != nil {
+		reader.concurrent.stop()
 	}
 	if closer, ok := reader.r.(io.Closer); ok {
 		return closer.Close()
@@ -296,6 +627,29 @@ func (reader *DataFileReader) Close() error {
 	return nil
 }
 
+// positionReader keeps track of the byte offset in the input.
+type positionReader struct {
+	r   io.Reader
+	pos int64
+}
+
+func (p *positionReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.pos += int64(n)
+	return n, err
+}
+
+// byteReader reads one byte at a time, so nothing is read past the sync marker of non-seekable inputs.
+type byteReader struct {
+	r io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.r, buf[:])
+	return buf[0], err
+}
+
 ////////// DATA FILE WRITER
 
 // DataFileWriter lets you write object container files.
@@ -303,90 +657,436 @@ type DataFileWriter struct {
 	output      io.Writer
 	outputEnc   *binaryEncoder
 	datumWriter DatumWriter
+	schema      Schema
 	sync        []byte
+	codecName   string
+	codec       Codec
+
+	// blocks are flushed when they reach either limit, if set
+	maxBlockSize  int
+	maxBlockCount int64
+
+	// the header is written with the first block, until then metadata can be set
+	header *objFileHeader
 
 	// current block is buffered until flush
 	blockBuf   *bytes.Buffer
 	blockCount int64
 	blockEnc   *binaryEncoder
+
+	// set by WithCompressionWorkers
+	concurrent *concurrentEncoder
+
+	// the first error writing to output, the file can't be completed after it
+	err error
+}
+
+// DataFileWriterOption configures a DataFileWriter created by NewDataFileWriter.
+type DataFileWriterOption func(*dataFileWriterOptions)
+
+type dataFileWriterOptions struct {
+	codec      string
+	level      int
+	schema     Schema
+	blockSize  int
+	blockCount int64
+	workers    int
+}
+
+// DefaultBlockSize is the size of the serialized datums in a block at which DataFileWriter flushes it by default, the
+// same as the Java implementation.
+const DefaultBlockSize = 64000
+
+func newDataFileWriterOptions(opts []DataFileWriterOption) *dataFileWriterOptions {
+	options := &dataFileWriterOptions{level: DefaultCompressionLevel, blockSize: DefaultBlockSize}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithCodec sets the name of the registered codec blocks are compressed with, "null" by default.
+// When appending, it must be the codec of the file.
+func WithCodec(name string) DataFileWriterOption {
+	return func(o *dataFileWriterOptions) {
+		o.codec = name
+	}
+}
+
+// WithCompressionLevel sets the compression level of the codec, e.g. 1 (fastest) to 9 (best) for deflate.
+func WithCompressionLevel(level int) DataFileWriterOption {
+	return func(o *dataFileWriterOptions) {
+		o.level = level
+	}
+}
+
+// WithBlockSize flushes blocks once the serialized datums reach the given size in bytes, before compression.
+// It defaults to DefaultBlockSize, 0 only flushes blocks when Flush is called.
+func WithBlockSize(size int) DataFileWriterOption {
+	return func(o *dataFileWriterOptions) {
+		o.blockSize = size
+	}
+}
+
+// WithBlockCount flushes blocks once they contain the given number of datums, 0 (the default) doesn't limit the
+// number of datums in a block.
+func WithBlockCount(count int64) DataFileWriterOption {
+	return func(o *dataFileWriterOptions) {
+		o.blockCount = count
+	}
+}
+
+// WithCompressionWorkers compresses blocks on the given number of goroutines while datums are written, blocks are
+// still written in order. At most twice as many blocks as workers are queued, Write blocks until there is room for
+// another one. An error compressing or writing a queued block is returned by the following Write, Flush or Close.
+func WithCompressionWorkers(workers int) DataFileWriterOption {
+	return func(o *dataFileWriterOptions) {
+		o.workers = workers
+	}
+}
+
+// WithSchema checks that the file is written with a schema with the same Parsing Canonical Form. It is mostly useful
+// with AppendDataFileWriter, which also uses it to start empty files.
+func WithSchema(schema Schema) DataFileWriterOption {
+	return func(o *dataFileWriterOptions) {
+		o.schema = schema
+	}
 }
 
 // NewDataFileWriter creates a new DataFileWriter for given output and schema using the given DatumWriter to write the data to that Writer.
-// May return an error if writing fails.
-func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter) (writer *DataFileWriter, err error) {
-	encoder := newBinaryEncoder(output)
-	switch w := datumWriter.(type) {
-	case *SpecificDatumWriter:
-		w.SetSchema(schema)
-	case *GenericDatumWriter:
-		w.SetSchema(schema)
+// May return an error if writing fails or the options are invalid.
+func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter, opts ...DataFileWriterOption) (writer *DataFileWriter, err error) {
+	options := newDataFileWriterOptions(opts)
+	if options.codec == "" {
+		options.codec = "null"
+	}
+	if options.schema != nil && RabinFingerprint(options.schema) != RabinFingerprint(schema) {
+		return nil, errors.New("DataFileWriter: schema doesn't match the schema of the file")
 	}
 
-	sync := []byte("1234567890abcdef") // TODO come up with other sync value
+	sync := make([]byte, containerSyncSize)
+	if _, err = rand.Read(sync); err != nil {
+		return nil, err
+	}
 
-	header := &objFileHeader{
+	if writer, err = newDataFileWriter(output, schema, datumWriter, options, sync); err != nil {
+		return nil, err
+	}
+	writer.header = &objFileHeader{
 		Magic: magic,
 		Meta: map[string][]byte{
 			schemaKey: []byte(schema.String()),
-			codecKey:  []byte("null"),
+			codecKey:  []byte(options.codec),
 		},
 		Sync: sync,
 	}
-	headerWriter := NewSpecificDatumWriter()
-	headerWriter.SetSchema(objHeaderSchema)
-	if err = headerWriter.Write(header, encoder); err != nil {
-		return
+	return writer, nil
+}
+
+// AppendDataFileWriter creates a DataFileWriter which appends blocks to an existing object container file, reusing
+// its schema, codec and sync marker. The options WithSchema and WithCodec check the schema and codec of the file.
+// An empty file is started with the schema given by WithSchema.
+//
+// Blocks are written after seeking to the end of the data, so files must not be opened with os.O_APPEND, which
+// ignores the position when writing.
+func AppendDataFileWriter(rw io.ReadWriteSeeker, opts ...DataFileWriterOption) (*DataFileWriter, error) {
+	options := newDataFileWriterOptions(opts)
+	end, err := rw.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if end == 0 {
+		if options.schema == nil {
+			return nil, errors.New("DataFileWriter: appending to an empty file needs a schema")
+		}
+		return NewDataFileWriter(rw, options.schema, NewDatumWriter(options.schema), opts...)
+	}
+
+	if _, err := rw.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header, err := ReadDataFileHeader(rw)
+	if err != nil {
+		return nil, err
+	}
+	if options.schema != nil && RabinFingerprint(options.schema) != RabinFingerprint(header.Schema) {
+		return nil, errors.New("DataFileWriter: schema doesn't match the schema of the file")
+	}
+	if options.codec != "" && options.codec != header.Codec {
+		return nil, fmt.Errorf("DataFileWriter: codec %s doesn't match the codec of the file, %s", options.codec, header.Codec)
+	}
+	options.codec = header.Codec
+
+	writer, err := newDataFileWriter(rw, header.Schema, NewDatumWriter(header.Schema), options, header.Sync)
+	if err != nil {
+		return nil, err
+	}
+	if offset, err := writer.appendOffset(rw, header.DataOffset, end); err != nil {
+		return nil, err
+	} else if _, err := rw.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func newDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter, options *dataFileWriterOptions, sync []byte) (*DataFileWriter, error) {
+	codec, err := newCodec(options.codec, options.level)
+	if err != nil {
+		return nil, err
+	}
+	// fail early on codecs which can only decompress
+	if _, err := codec.Compress(nil); err != nil {
+		return nil, err
+	}
+	if options.workers < 0 {
+		return nil, fmt.Errorf("DataFileWriter: invalid number of compression workers %d", options.workers)
 	}
+
+	switch w := datumWriter.(type) {
+	case *SpecificDatumWriter:
+		w.SetSchema(schema)
+	case *GenericDatumWriter:
+		w.SetSchema(schema)
+	}
+
 	blockBuf := &bytes.Buffer{}
-	writer = &DataFileWriter{
-		output:      output,
-		outputEnc:   encoder,
-		datumWriter: datumWriter,
-		sync:        sync,
-		blockBuf:    blockBuf,
-		blockEnc:    newBinaryEncoder(blockBuf),
+	w := &DataFileWriter{
+		output:        output,
+		outputEnc:     newBinaryEncoder(output),
+		datumWriter:   datumWriter,
+		schema:        schema,
+		sync:          sync,
+		codecName:     options.codec,
+		codec:         codec,
+		maxBlockSize:  options.blockSize,
+		maxBlockCount: options.blockCount,
+		blockBuf:      blockBuf,
+		blockEnc:      newBinaryEncoder(blockBuf),
+	}
+	if options.workers > 0 {
+		w.concurrent = newConcurrentEncoder(w, options.workers)
 	}
+	return w, nil
+}
 
-	return
+// maxEmptyBlockSize bounds the compressed size of the empty block looked for at the end of files.
+const maxEmptyBlockSize = 256
+
+// appendOffset returns where blocks are appended to a file. The empty block Close writes at the end of the file is
+// overwritten, as some readers, like Java's, stop at the first empty block. It is recognized by its framing, whatever
+// its compressed data: a count of 0 and a size reaching the final sync marker, after the header or another sync marker.
+func (w *DataFileWriter) appendOffset(r io.ReadSeeker, headerEnd, end int64) (int64, error) {
+	from := end - (containerSyncSize + 1 + binary.MaxVarintLen64 + maxEmptyBlockSize + containerSyncSize)
+	if from < headerEnd {
+		from = headerEnd
+	}
+	tail := make([]byte, end-from)
+	if _, err := r.Seek(from, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return 0, err
+	}
+	if !bytes.HasSuffix(tail, w.sync) {
+		return end, nil
+	}
+	for i := range tail {
+		if tail[i] != 0 {
+			continue
+		}
+		start := from + int64(i)
+		if start != headerEnd && (i < containerSyncSize || !bytes.Equal(tail[i-containerSyncSize:i], w.sync)) {
+			continue
+		}
+		// longs are zig-zag varints
+		size, n := binary.Varint(tail[i+1:])
+		if n > 0 && size >= 0 && int64(i+1+n)+size+containerSyncSize == int64(len(tail)) {
+			return start, nil
+		}
+	}
+	return end, nil
+}
+
+// SetMeta adds a key to the metadata in the file header. Metadata can only be set before the first datum is
+// written, keys starting with "avro." are reserved.
+func (w *DataFileWriter) SetMeta(key string, value []byte) error {
+	if w.header == nil || w.blockCount > 0 {
+		return errors.New("DataFileWriter: metadata can only be set before the first write")
+	}
+	if strings.HasPrefix(key, "avro.") {
+		return fmt.Errorf("DataFileWriter: metadata key %s is reserved", key)
+	}
+	w.header.Meta[key] = value
+	return nil
+}
+
+// SetMetaString adds a key with a string value to the metadata in the file header, see SetMeta.
+func (w *DataFileWriter) SetMetaString(key string, value string) error {
+	return w.SetMeta(key, []byte(value))
 }
 
 // Write out a single datum.
 //
 // Encoded datums are buffered internally and will not be written to the
-// underlying io.Writer until Flush() is called.
+// underlying io.Writer until Flush() is called, or the block reaches the
+// size or count set by WithBlockSize and WithBlockCount. A datum which
+// fails to encode is left out of the block.
 func (w *DataFileWriter) Write(v interface{}) error {
+	if err := w.checkErr(); err != nil {
+		return err
+	}
+	size := w.blockBuf.Len()
+	if err := w.datumWriter.Write(v, w.blockEnc); err != nil {
+		w.blockBuf.Truncate(size)
+		return err
+	}
 	w.blockCount++
-	err := w.datumWriter.Write(v, w.blockEnc)
-	return err
+	if (w.maxBlockSize > 0 && w.blockBuf.Len() >= w.maxBlockSize) ||
+		(w.maxBlockCount > 0 && w.blockCount >= w.maxBlockCount) {
+		return w.actuallyFlush()
+	}
+	return nil
+}
+
+// AppendBlocksFrom copies the remaining blocks of reader to the end of the file, after flushing the datums written so
+// far. The schemas must have the same Parsing Canonical Form. Blocks are copied as they are when both files use the
+// same codec, otherwise they are decompressed and compressed again. Only the sync markers change, the metadata of the
+// file read isn't copied.
+//
+// The current block of the reader can't be partially read. Afterwards the reader is at the end of its file.
+func (w *DataFileWriter) AppendBlocksFrom(reader *DataFileReader) error {
+	if reader.concurrent != nil {
+		return errDecodingConcurrently
+	}
+	if RabinFingerprint(reader.schema) != RabinFingerprint(w.schema) {
+		return errors.New("DataFileWriter: schema doesn't match the schema of the file")
+	}
+	if block := reader.block; block != nil && block.BlockRemaining > 0 && block.BlockRemaining < block.NumEntries {
+		return errors.New("DataFileWriter: can't append a partially read block")
+	}
+	if w.blockCount > 0 {
+		if err := w.actuallyFlush(); err != nil {
+			return err
+		}
+	}
+	if err := w.checkErr(); err != nil {
+		return err
+	}
+	if reader.err != nil {
+		return reader.Err()
+	}
+
+	count, block := int64(0), []byte(nil)
+	if reader.block != nil {
+		count, block = reader.block.BlockRemaining, reader.block.raw
+		reader.block.BlockRemaining = 0
+	}
+	for {
+		if count > 0 {
+			if reader.header.Codec != w.codecName {
+				data, err := reader.decompress(block)
+				if err != nil {
+					return reader.stop(err)
+				}
+				if block, err = w.codec.Compress(data); err != nil {
+					return err
+				}
+			}
+			if err := w.appendBlock(count, block); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if count, block, err = reader.readRawBlock(); err == io.EOF {
+			reader.stop(err)
+			return nil
+		} else if err != nil {
+			return reader.stop(err)
+		}
+		// the block is copied and not read through the reader
+		reader.block = &DataBlock{raw: block, NumEntries: count, BlockSize: len(block)}
+	}
+}
+
+// appendBlock writes a compressed block, or queues it behind the blocks being compressed.
+func (w *DataFileWriter) appendBlock(count int64, block []byte) error {
+	header, err := w.encodeHeader()
+	if err != nil {
+		return err
+	}
+	if w.concurrent != nil {
+		w.concurrent.write(header, count, block)
+		return nil
+	}
+	if err := w.writeBlock(header, count, block); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// Err returns the first error writing to the underlying io.Writer.
+// Once it occurs, Write, Flush and Close keep returning it.
+func (w *DataFileWriter) Err() error {
+	return w.checkErr()
+}
+
+// checkErr picks up errors of blocks written by the compression workers.
+func (w *DataFileWriter) checkErr() error {
+	if w.err == nil && w.concurrent != nil {
+		w.err = w.concurrent.error()
+	}
+	return w.err
 }
 
 // Flush out any previously written datums to our underlying io.Writer.
-// Does nothing if no datums had previously been written.
+// The header is written by the first Flush, even without datums, so the
+// output is a valid empty file from then on. Metadata can't be set after it.
 //
 // It's up to the library user to decide how often to flush; doing it
 // often will spend a lot of time on tiny I/O but save memory.
+//
+// With WithCompressionWorkers, Flush waits until all queued blocks are written.
 func (w *DataFileWriter) Flush() error {
 	if w.blockCount > 0 {
-		return w.actuallyFlush()
+		if err := w.actuallyFlush(); err != nil {
+			return err
+		}
+	} else if err := w.flushHeader(); err != nil {
+		return err
 	}
-	return nil
+	if w.concurrent != nil {
+		w.concurrent.wait()
+	}
+	return w.checkErr()
 }
 
 func (w *DataFileWriter) actuallyFlush() error {
-	// Write the block count and length directly to output
-	w.outputEnc.WriteLong(w.blockCount)
-	w.outputEnc.WriteLong(int64(w.blockBuf.Len()))
-
-	// copy the buffer which is the block buf to output
-	_, err := io.Copy(w.output, w.blockBuf)
-	if err != nil {
+	if err := w.checkErr(); err != nil {
 		return err
 	}
 
-	// write the sync bytes
-	_, err = w.output.Write(w.sync)
-	if err != nil {
-		return err
+	if w.concurrent != nil {
+		header, err := w.encodeHeader()
+		if err != nil {
+			return err
+		}
+		// the buffer is reused for the next block while this one is compressed
+		w.concurrent.flush(header, w.blockCount, append([]byte(nil), w.blockBuf.Bytes()...))
+	} else {
+		block, err := w.codec.Compress(w.blockBuf.Bytes())
+		if err != nil {
+			return err
+		}
+		header, err := w.encodeHeader()
+		if err != nil {
+			return err
+		}
+		if err := w.writeBlock(header, w.blockCount, block); err != nil {
+			w.err = err
+			return err
+		}
 	}
 
 	w.blockBuf.Reset() // allow blockbuf's internal memory to be reused
@@ -394,6 +1094,81 @@ func (w *DataFileWriter) actuallyFlush() error {
 	return nil
 }
 
+// pendingBytes returns the size of the datums written but not yet passed to the output: the current block and the
+// blocks queued for compression, counted before compression.
+func (w *DataFileWriter) pendingBytes() int64 {
+	var pending int64
+	if w.blockCount > 0 {
+		pending = blockFramingSize(w.blockCount, w.blockBuf.Len()) + int64(w.blockBuf.Len())
+	}
+	if w.concurrent != nil {
+		pending += w.concurrent.queuedBytes()
+	}
+	return pending
+}
+
+// blockFramingSize returns the size of the count, size and sync marker written around a block.
+func blockFramingSize(count int64, size int) int64 {
+	var buf [binary.MaxVarintLen64]byte
+	return int64(binary.PutVarint(buf[:], count) + binary.PutVarint(buf[:], int64(size)) + containerSyncSize)
+}
+
+// flushHeader writes the header if it hasn't been written yet. No blocks are queued before the header.
+func (w *DataFileWriter) flushHeader() error {
+	if err := w.checkErr(); err != nil {
+		return err
+	}
+	header, err := w.encodeHeader()
+	if err != nil || len(header) == 0 {
+		return err
+	}
+	if _, err := w.output.Write(header); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// encodeHeader returns the header if it hasn't been written yet.
+func (w *DataFileWriter) encodeHeader() ([]byte, error) {
+	if w.header == nil {
+		return nil, nil
+	}
+	buf := &bytes.Buffer{}
+	headerWriter := NewSpecificDatumWriter()
+	headerWriter.SetSchema(objHeaderSchema)
+	if err := headerWriter.Write(w.header, NewBinaryEncoder(buf)); err != nil {
+		return nil, err
+	}
+	w.header = nil
+	return buf.Bytes(), nil
+}
+
+// writeBlock writes a compressed block to output, after the header for the first one.
+func (w *DataFileWriter) writeBlock(header []byte, count int64, block []byte) error {
+	if len(header) > 0 {
+		if _, err := w.output.Write(header); err != nil {
+			return err
+		}
+	}
+
+	// Write the block count and length directly to output
+	w.outputEnc.WriteLong(count)
+	w.outputEnc.WriteLong(int64(len(block)))
+	if err := w.outputEnc.Err(); err != nil {
+		return err
+	}
+
+	// write the compressed block to output
+	if _, err := w.output.Write(block); err != nil {
+		return err
+	}
+
+	// write the sync bytes
+	_, err := w.output.Write(w.sync)
+	return err
+}
+
 // Close this DataFileWriter.
 // This is required to finish out the data file format.
 // After Close() is called, this DataFileWriter cannot be used anymore.
@@ -402,38 +1177,26 @@ func (w *DataFileWriter) Close() error {
 	if err == nil {
 		// Do an empty flush to signal end of data file format
 		err = w.actuallyFlush()
-
-		if err == nil {
-			// Clean up references.
-			w.output, w.outputEnc, w.datumWriter = nil, nil, nil
-			w.blockBuf, w.blockEnc = nil, nil
+	}
+	if w.concurrent != nil {
+		// wait for the queued blocks and stop the workers
+		if closeErr := w.concurrent.close(); err == nil {
+			err = closeErr
 		}
+		w.concurrent = nil
+	}
+	if err == nil {
+		// Clean up references.
+		w.output, w.outputEnc, w.datumWriter = nil, nil, nil
+		w.blockBuf, w.blockEnc = nil, nil
 	}
 	return err
 }
 
-type fileCodec interface {
-	CodecReader(io.Reader) (io.Reader, func())
-}
-
-type nullCodec struct{}
-
-func (nullCodec) CodecReader(r io.Reader) (io.Reader, func()) {
-	return r, nil
-}
-
-type flateCodec struct{}
-
-func (flateCodec) CodecReader(r io.Reader) (io.Reader, func()) {
-	flateReader := flate.NewReader(r)
-	return flateReader, func() { flateReader.Close() }
-}
-
 // DataBlock is a structure that holds a certain amount of entries and the actual buffer to read from.
 type DataBlock struct {
-	reader  io.Reader
-	closer  func()
 	decoder Decoder
+	raw     []byte // the block as read, before decompression
 
 	// Number of entries encoded in Data.
 	NumEntries int64
@@ -444,10 +1207,3 @@ type DataBlock struct {
 	// Number of unread entries in this DataBlock.
 	BlockRemaining int64
 }
-
-func (block *DataBlock) runCloser() {
-	if block.closer != nil {
-		block.closer()
-		block.closer = nil
-	}
-}
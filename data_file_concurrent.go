@@ -0,0 +1,306 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// concurrentDecoder decompresses and decodes the blocks of a DataFileReader on a pool of goroutines. Blocks are read
+// sequentially and queued in file order, the queue is bounded so memory use is too.
+type concurrentDecoder struct {
+	queue   chan chan decodedBlock
+	done    chan struct{}
+	stopped sync.Once
+	datums  []interface{}
+}
+
+var errDecodingConcurrently = errors.New("DataFileReader: not possible while decoding concurrently")
+
+type decodedBlock struct {
+	datums []interface{}
+	err    error
+}
+
+type blockJob struct {
+	count  int64
+	data   []byte
+	result chan decodedBlock
+}
+
+// DecodeConcurrently makes the reader decompress and decode the following blocks on the given number of goroutines,
+// while the caller uses HasNext and Next as before. Datums are returned in file order.
+//
+// Datums are decoded into values returned by newDatum, like &MyStruct{} or NewGenericRecord(schema), and Next copies
+// them into its argument, which must be a pointer to the same type, or a **GenericRecord for *GenericRecord values.
+// NextBlock, SeekToBlock, Sync, Tell and PastSync can't be used afterwards. Close stops the goroutines.
+func (reader *DataFileReader) DecodeConcurrently(workers int, newDatum func() interface{}) error {
+	if reader.concurrent != nil {
+		return errDecodingConcurrently
+	}
+	if workers < 1 {
+		return fmt.Errorf("DataFileReader: invalid number of workers %d", workers)
+	}
+
+	c := &concurrentDecoder{
+		queue: make(chan chan decodedBlock, 2*workers),
+		done:  make(chan struct{}),
+	}
+	// the rest of the current block is decoded right away
+	if reader.block != nil {
+		for ; reader.block.BlockRemaining > 0; reader.block.BlockRemaining-- {
+			datum := newDatum()
+			if err := reader.datum.Read(datum, reader.block.decoder); err != nil {
+				return reader.stop(err)
+			}
+			c.datums = append(c.datums, datum)
+		}
+	}
+	reader.concurrent = c
+	if reader.err != nil {
+		close(c.queue)
+		return nil
+	}
+
+	jobs := make(chan blockJob)
+	for i := 0; i < workers; i++ {
+		go reader.decodeBlocks(jobs, newDatum)
+	}
+	go reader.readBlocks(jobs)
+	return nil
+}
+
+// readBlocks reads raw blocks and queues them for decoding, until the end of the file or the first error.
+func (reader *DataFileReader) readBlocks(jobs chan<- blockJob) {
+	c := reader.concurrent
+	defer close(c.queue)
+	defer close(jobs)
+
+	for {
+		blockCount, data, err := reader.readRawBlock()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			result := make(chan decodedBlock, 1)
+			result <- decodedBlock{err: err}
+			select {
+			case c.queue <- result:
+			case <-c.done:
+			}
+			return
+		}
+		// the block is decoded by the workers, the next one starts after its sync marker
+		reader.block = &DataBlock{raw: data, NumEntries: blockCount, BlockSize: len(data)}
+
+		job := blockJob{count: blockCount, data: data, result: make(chan decodedBlock, 1)}
+		select {
+		case jobs <- job:
+		case <-c.done:
+			return
+		}
+		select {
+		case c.queue <- job.result:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (reader *DataFileReader) decodeBlocks(jobs <-chan blockJob, newDatum func() interface{}) {
+	for job := range jobs {
+		job.result <- reader.decodeBlock(job, newDatum)
+	}
+}
+
+func (reader *DataFileReader) decodeBlock(job blockJob, newDatum func() interface{}) decodedBlock {
+	data, err := reader.decompress(job.data)
+	if err != nil {
+		return decodedBlock{err: err}
+	}
+	dec := NewBinaryDecoder(data)
+	datums := make([]interface{}, job.count)
+	for i := range datums {
+		datums[i] = newDatum()
+		if err := reader.datum.Read(datums[i], dec); err != nil {
+			return decodedBlock{err: err}
+		}
+	}
+	return decodedBlock{datums: datums}
+}
+
+// advanceConcurrently waits for the next decoded block if the current one is used up.
+func (reader *DataFileReader) advanceConcurrently() bool {
+	c := reader.concurrent
+	for len(c.datums) == 0 {
+		if reader.err != nil {
+			return false
+		}
+		result, ok := <-c.queue
+		if !ok {
+			reader.stop(io.EOF)
+			return false
+		}
+		block := <-result
+		if block.err != nil {
+			reader.stop(block.err)
+			return false
+		}
+		c.datums = block.datums
+	}
+	return true
+}
+
+func (reader *DataFileReader) nextConcurrently(v interface{}) error {
+	if !reader.advanceConcurrently() {
+		return reader.err
+	}
+	datum := reader.concurrent.datums[0]
+	if err := assignDatum(v, datum); err != nil {
+		return err
+	}
+	reader.concurrent.datums[0] = nil
+	reader.concurrent.datums = reader.concurrent.datums[1:]
+	return nil
+}
+
+func (c *concurrentDecoder) stop() {
+	c.stopped.Do(func() { close(c.done) })
+}
+
+// assignDatum copies a decoded datum into the value passed to Next.
+func assignDatum(v interface{}, datum interface{}) error {
+	target, source := reflect.ValueOf(v), reflect.ValueOf(datum)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return errors.New("Not applicable for non-pointer types or nil")
+	}
+	switch {
+	case source.Type() == target.Type() && source.Kind() == reflect.Ptr:
+		target.Elem().Set(source.Elem())
+	case source.Type() == target.Type().Elem():
+		target.Elem().Set(source)
+	default:
+		return fmt.Errorf("DataFileReader: can't read a %v into a %v", source.Type(), target.Type())
+	}
+	return nil
+}
+
+// concurrentEncoder compresses the blocks of a DataFileWriter on a pool of goroutines and writes them to the output
+// in order from another one. The queue is bounded so memory use is too.
+type concurrentEncoder struct {
+	jobs  chan *compressJob
+	queue chan *compressJob
+	done  chan struct{}
+
+	// the bytes of the blocks queued but not written yet, counted before compression for those still to compress
+	queued int64
+
+	mu  sync.Mutex
+	err error
+}
+
+type compressJob struct {
+	header []byte
+	count  int64
+	data   []byte
+	size   int64
+	result chan compressedBlock
+
+	// set instead for Flush to wait until the blocks before it are written
+	written chan struct{}
+}
+
+type compressedBlock struct {
+	data []byte
+	err  error
+}
+
+func newConcurrentEncoder(w *DataFileWriter, workers int) *concurrentEncoder {
+	c := &concurrentEncoder{
+		jobs:  make(chan *compressJob),
+		queue: make(chan *compressJob, 2*workers),
+		done:  make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range c.jobs {
+				data, err := w.codec.Compress(job.data)
+				job.result <- compressedBlock{data: data, err: err}
+			}
+		}()
+	}
+	go c.writeBlocks(w)
+	return c
+}
+
+// writeBlocks writes the compressed blocks in order. After the first error the remaining ones are dropped.
+func (c *concurrentEncoder) writeBlocks(w *DataFileWriter) {
+	defer close(c.done)
+	for job := range c.queue {
+		if job.written != nil {
+			close(job.written)
+			continue
+		}
+		block := <-job.result
+		if c.error() != nil {
+			atomic.AddInt64(&c.queued, -job.size)
+			continue
+		}
+		err := block.err
+		if err == nil {
+			err = w.writeBlock(job.header, job.count, block.data)
+		}
+		atomic.AddInt64(&c.queued, -job.size)
+		if err != nil {
+			c.mu.Lock()
+			c.err = err
+			c.mu.Unlock()
+		}
+	}
+}
+
+// flush queues a block, waiting while the queue is full.
+func (c *concurrentEncoder) flush(header []byte, count int64, data []byte) {
+	job := &compressJob{header: header, count: count, data: data, result: make(chan compressedBlock, 1)}
+	job.size = int64(len(header)+len(data)) + blockFramingSize(count, len(data))
+	atomic.AddInt64(&c.queued, job.size)
+	c.queue <- job
+	c.jobs <- job
+}
+
+// write queues a block which is already compressed.
+func (c *concurrentEncoder) write(header []byte, count int64, data []byte) {
+	job := &compressJob{header: header, count: count, result: make(chan compressedBlock, 1)}
+	job.size = int64(len(header)+len(data)) + blockFramingSize(count, len(data))
+	job.result <- compressedBlock{data: data}
+	atomic.AddInt64(&c.queued, job.size)
+	c.queue <- job
+}
+
+// queuedBytes returns the size of the blocks queued but not written yet.
+func (c *concurrentEncoder) queuedBytes() int64 {
+	return atomic.LoadInt64(&c.queued)
+}
+
+// wait returns once the blocks queued so far are written.
+func (c *concurrentEncoder) wait() {
+	written := make(chan struct{})
+	c.queue <- &compressJob{written: written}
+	<-written
+}
+
+// close waits for the queued blocks and stops the goroutines.
+func (c *concurrentEncoder) close() error {
+	close(c.queue)
+	close(c.jobs)
+	<-c.done
+	return c.error()
+}
+
+func (c *concurrentEncoder) error() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
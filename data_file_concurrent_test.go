@@ -0,0 +1,121 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDataFileReaderDecodeConcurrently(t *testing.T) {
+	encoded := writeTestDataFile(t, 2500, WithCodec("deflate"))
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p primitive
+	for i := 0; i < 30; i++ {
+		assert(t, dfr.Next(&p), nil)
+	}
+	assert(t, dfr.DecodeConcurrently(4, func() interface{} { return &primitive{} }), nil)
+	assert(t, dfr.DecodeConcurrently(4, func() interface{} { return &primitive{} }), errDecodingConcurrently)
+	assert(t, dfr.SeekToBlock(0), errDecodingConcurrently)
+
+	n := int64(30)
+	for dfr.HasNext() {
+		assert(t, dfr.Next(&p), nil)
+		assert(t, p.LongField, n)
+		n++
+	}
+	assert(t, n, int64(2500))
+	assert(t, dfr.Err(), nil)
+	assert(t, dfr.Close(), nil)
+}
+
+func TestDataFileReaderDecodeConcurrently_generic(t *testing.T) {
+	encoded := writeTestDataFile(t, 250)
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := dfr.Schema()
+	assert(t, dfr.DecodeConcurrently(2, func() interface{} { return NewGenericRecord(schema) }), nil)
+
+	var record *GenericRecord
+	assert(t, dfr.Next(&record), nil)
+	assert(t, record.Get("longField"), int64(0))
+	var p primitive
+	assert(t, dfr.Next(&p).Error(), "DataFileReader: can't read a *avro.GenericRecord into a *avro.primitive")
+	assert(t, dfr.Next(record), nil)
+	assert(t, record.Get("longField"), int64(1))
+	assert(t, dfr.Close(), nil)
+}
+
+func TestDataFileReaderDecodeConcurrently_corrupt(t *testing.T) {
+	encoded := writeTestDataFile(t, 250, WithCodec("snappy"))
+	// the checksum of the last block, before its sync marker and the empty block at the end
+	encoded[len(encoded)-2*containerSyncSize-8] ^= 0xff
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.DecodeConcurrently(3, func() interface{} { return &primitive{} }), nil)
+	n := 0
+	for dfr.HasNext() {
+		var p primitive
+		assert(t, dfr.Next(&p), nil)
+		n++
+	}
+	assert(t, n, 200)
+	assert(t, dfr.Err(), ErrCorruptBlock)
+	assert(t, dfr.Close(), nil)
+}
+
+func TestDataFileReaderDecodeConcurrently_close(t *testing.T) {
+	encoded := writeTestDataFile(t, 5000)
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.DecodeConcurrently(1, func() interface{} { return &primitive{} }), nil)
+	var p primitive
+	assert(t, dfr.Next(&p), nil)
+	// stops the goroutines, which are waiting for the queue to drain
+	assert(t, dfr.Close(), nil)
+}
+
+func TestDataFileWriterCompressionWorkers(t *testing.T) {
+	for _, codec := range []string{"null", "deflate", "zstandard"} {
+		encoded := writeTestDataFile(t, 2500, WithCodec(codec), WithCompressionWorkers(4))
+		values := readTestDataFile(t, encoded)
+		assert(t, len(values), 2500)
+		for i, value := range values {
+			if value != int64(i) {
+				t.Fatalf("%s: expected %d, got %d", codec, i, value)
+			}
+		}
+	}
+
+	schema := MustParseSchema(primitiveSchemaRaw)
+	_, err := NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter(), WithCompressionWorkers(-1))
+	assert(t, err.Error(), "DataFileWriter: invalid number of compression workers -1")
+}
+
+func TestDataFileWriterCompressionWorkers_errors(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	dfw, err := NewDataFileWriter(&limitedWriter{n: 2000}, schema, NewSpecificDatumWriter(),
+		WithCompressionWorkers(2), WithBlockCount(10))
+	assert(t, err, nil)
+	// blocks are written in the background, the error shows up later
+	for i := 0; i < 1000 && err == nil; i++ {
+		err = dfw.Write(&primitive{LongField: int64(i)})
+	}
+	assert(t, err, errDiskFull)
+	assert(t, dfw.Err(), errDiskFull)
+	assert(t, dfw.Close(), errDiskFull)
+
+	dfw, err = NewDataFileWriter(&limitedWriter{n: 2000}, schema, NewSpecificDatumWriter(), WithCompressionWorkers(2))
+	assert(t, err, nil)
+	for i := 0; i < 100; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, dfw.Close(), errDiskFull)
+}
@@ -0,0 +1,178 @@
+package avro
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+// blockIndexSchemaRaw is the schema of block index sidecars. The format is specific to this package.
+const blockIndexSchemaRaw = `{"type": "record", "name": "com.github.daemonl.avro.BlockIndex",
+ "fields" : [
+   {"name": "sync", "type": {"type": "fixed", "name": "Sync", "size": 16}},
+   {"name": "blocks", "type": {"type": "array", "items": {"type": "record", "name": "IndexedBlock", "fields": [
+     {"name": "offset", "type": "long"},
+     {"name": "firstRecord", "type": "long"},
+     {"name": "count", "type": "long"},
+     {"name": "size", "type": "long"}
+   ]}}}
+  ]
+}`
+
+var blockIndexSchema = Prepare(MustParseSchema(blockIndexSchemaRaw))
+
+// BlockIndex lists the blocks of an object container file, so a DataFileReader can seek to any record with
+// SeekToRecord. It is stored next to the file with Write, and loaded with ReadBlockIndex and SetBlockIndex.
+type BlockIndex struct {
+	// Sync is the sync marker of the file, to check the index belongs to it.
+	Sync []byte `avro:"sync"`
+
+	// Blocks are the non-empty blocks of the file, in order.
+	Blocks []IndexedBlock `avro:"blocks"`
+}
+
+// IndexedBlock is the position of a block in an object container file.
+type IndexedBlock struct {
+	// Offset is the byte offset of the block, as returned by DataFileReader.Tell.
+	Offset int64 `avro:"offset"`
+
+	// FirstRecord is the number of records in the blocks before this one.
+	FirstRecord int64 `avro:"firstRecord"`
+
+	// Count is the number of records in the block.
+	Count int64 `avro:"count"`
+
+	// Size is the size of the block in bytes, compressed.
+	Size int64 `avro:"size"`
+}
+
+// BuildBlockIndex walks the block headers of an object container file without decompressing them. The data of the
+// blocks is skipped with Seek if r is an io.Seeker, and read otherwise.
+func BuildBlockIndex(r io.Reader) (*BlockIndex, error) {
+	in := &positionReader{r: r}
+	seeker, _ := r.(io.Seeker)
+	if seeker != nil {
+		var err error
+		if in.pos, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+	header, err := ReadDataFileHeader(in)
+	if err != nil {
+		return nil, err
+	}
+	dec := NewBinaryDecoderReader(in)
+
+	index := &BlockIndex{Sync: header.Sync}
+	records := int64(0)
+	syncBuffer := make([]byte, containerSyncSize)
+	for {
+		offset := in.pos
+		blockCount, blockSize, err := readBlockHeader(dec)
+		if err == io.EOF {
+			return index, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if seeker != nil {
+			if in.pos, err = seeker.Seek(blockSize, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		} else if _, err := io.CopyN(ioutil.Discard, in, blockSize); err != nil {
+			return nil, err
+		}
+		if err := readSync(in, header.Sync, syncBuffer); err != nil {
+			return nil, err
+		}
+
+		if blockCount > 0 {
+			index.Blocks = append(index.Blocks, IndexedBlock{
+				Offset:      offset,
+				FirstRecord: records,
+				Count:       blockCount,
+				Size:        blockSize,
+			})
+			records += blockCount
+		}
+	}
+}
+
+// Records returns the number of records in the file.
+func (index *BlockIndex) Records() int64 {
+	if len(index.Blocks) == 0 {
+		return 0
+	}
+	last := index.Blocks[len(index.Blocks)-1]
+	return last.FirstRecord + last.Count
+}
+
+// Write writes the index in the single-object encoding, with a schema specific to this package.
+func (index *BlockIndex) Write(w io.Writer) error {
+	if message, err := NewMessageEncoder(blockIndexSchema).Encode(index); err != nil {
+		return err
+	} else {
+		_, err = w.Write(message)
+		return err
+	}
+}
+
+// ReadBlockIndex reads an index written by BlockIndex.Write.
+func ReadBlockIndex(r io.Reader) (*BlockIndex, error) {
+	message, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	index := &BlockIndex{}
+	if err := NewMessageDecoder(nil, NewSchemaStore(blockIndexSchema)).Decode(message, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// SetBlockIndex loads the index of the file for SeekToRecord. The index must have been built from the same file.
+func (reader *DataFileReader) SetBlockIndex(index *BlockIndex) error {
+	if !bytes.Equal(index.Sync, reader.header.Sync) {
+		return errors.New("DataFileReader: the block index belongs to another file")
+	}
+	reader.index = index
+	return nil
+}
+
+// SeekToRecord moves to the record with the given number, counting from 0, so the following Next reads it. It seeks
+// to its block with the index loaded by SetBlockIndex and skips the records before it in the block.
+func (reader *DataFileReader) SeekToRecord(n int64) error {
+	if reader.concurrent != nil {
+		return errDecodingConcurrently
+	}
+	if reader.index == nil {
+		return errors.New("DataFileReader: no block index, see SetBlockIndex")
+	}
+	blocks := reader.index.Blocks
+	i := sort.Search(len(blocks), func(i int) bool {
+		return blocks[i].FirstRecord+blocks[i].Count > n
+	})
+	if n < 0 || i == len(blocks) {
+		return fmt.Errorf("DataFileReader: record %d is out of range", n)
+	}
+	if err := reader.SeekToBlock(blocks[i].Offset); err != nil {
+		return err
+	}
+	if reader.block == nil || reader.block.NumEntries != blocks[i].Count {
+		return reader.stop(errors.New("DataFileReader: the block index doesn't match the file"))
+	}
+
+	// skipped records are decoded with the writer schema, whatever the reader schema is
+	skipper := NewGenericDatumReader().SetSchema(reader.schema)
+	for skip := n - blocks[i].FirstRecord; skip > 0; skip-- {
+		var v interface{}
+		if err := skipper.Read(&v, reader.block.decoder); err != nil {
+			return reader.stop(err)
+		}
+		reader.block.BlockRemaining--
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBlockIndex(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, WithCodec("deflate")) // blocks of 100
+	for _, input := range []io.Reader{bytes.NewReader(encoded), struct{ io.Reader }{bytes.NewReader(encoded)}} {
+		index, err := BuildBlockIndex(input)
+		assert(t, err, nil)
+		assert(t, len(index.Blocks), 10)
+		assert(t, index.Records(), int64(1000))
+		assert(t, index.Blocks[3].FirstRecord, int64(300))
+		assert(t, index.Blocks[3].Count, int64(100))
+	}
+	index, err := BuildBlockIndex(bytes.NewReader(encoded))
+	assert(t, err, nil)
+
+	dfr, err := NewDataFileReaderFrom(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	for i, block := range index.Blocks {
+		assert(t, dfr.Tell(), block.Offset)
+		assert(t, dfr.block.BlockSize, int(block.Size))
+		if i < len(index.Blocks)-1 {
+			assert(t, dfr.NextBlock(), nil)
+		}
+	}
+
+	sidecar := &bytes.Buffer{}
+	assert(t, index.Write(sidecar), nil)
+	loaded, err := ReadBlockIndex(sidecar)
+	assert(t, err, nil)
+	assert(t, loaded, index)
+
+	assert(t, dfr.SeekToRecord(0).Error(), "DataFileReader: no block index, see SetBlockIndex")
+	assert(t, dfr.SetBlockIndex(loaded), nil)
+	var p primitive
+	for _, n := range []int64{567, 0, 999, 100, 99, 500} {
+		assert(t, dfr.SeekToRecord(n), nil)
+		assert(t, dfr.Next(&p), nil)
+		assert(t, p.LongField, n)
+	}
+	assert(t, dfr.Next(&p), nil)
+	assert(t, p.LongField, int64(501))
+	assert(t, dfr.SeekToRecord(1000).Error(), "DataFileReader: record 1000 is out of range")
+	assert(t, dfr.SeekToRecord(-1).Error(), "DataFileReader: record -1 is out of range")
+
+	other, err := BuildBlockIndex(bytes.NewReader(writeTestDataFile(t, 10)))
+	assert(t, err, nil)
+	assert(t, dfr.SetBlockIndex(other).Error(), "DataFileReader: the block index belongs to another file")
+}
@@ -0,0 +1,198 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// RollingDataFileWriter writes a stream of datums to a series of object container files, starting a new file once the
+// current one reaches MaxBytes, MaxRecords or MaxAge. Files are written under a temporary name in the same directory
+// and moved into place once complete, so readers never see partial files.
+//
+// Complete files are linked to their name rather than renamed, so files created under the same name in the meantime
+// are never replaced: the next free name is used instead.
+//
+// Files are only rotated by Write and Rotate, there are no background goroutines: to rotate idle streams on time, call
+// Rotate from a ticker. RollingDataFileWriter is not safe for concurrent use.
+type RollingDataFileWriter struct {
+	// MaxBytes rotates files once the bytes written plus the datums buffered in the current block or queued for
+	// compression reach it.
+	MaxBytes int64
+	// MaxRecords rotates files once they contain that many records.
+	MaxRecords int64
+	// MaxAge rotates files written to for that long, on the next Write.
+	MaxAge time.Duration
+	// OnRotate is called with the name of each complete file, e.g. to upload it. Its error is returned by the Write,
+	// Rotate or Close which completed the file.
+	OnRotate func(filename string) error
+	// FileMode is the permission of complete files, 0644 if zero.
+	FileMode os.FileMode
+
+	pattern string
+	schema  Schema
+	opts    []DataFileWriterOption
+	now     func() time.Time
+	seq     int
+
+	// the file being written, nil between files
+	current  *DataFileWriter
+	file     *os.File
+	written  *countingWriter
+	filename string
+	opened   time.Time
+	records  int64
+}
+
+// NewRollingDataFileWriter creates a RollingDataFileWriter. The names of the files are pattern formatted with
+// fmt.Sprintf and a sequence number starting at 0, like "events-%06d.avro", skipping names of existing files. Each
+// file is written by a DataFileWriter created with the given options.
+func NewRollingDataFileWriter(pattern string, schema Schema, opts ...DataFileWriterOption) *RollingDataFileWriter {
+	return &RollingDataFileWriter{
+		pattern: pattern,
+		schema:  schema,
+		opts:    opts,
+		now:     time.Now,
+	}
+}
+
+// Write writes a datum to the current file, starting a new one if needed.
+func (r *RollingDataFileWriter) Write(v interface{}) error {
+	if r.current != nil && r.MaxAge > 0 && r.now().Sub(r.opened) >= r.MaxAge {
+		if err := r.Rotate(); err != nil {
+			return err
+		}
+	}
+	if r.current == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if err := r.current.Write(v); err != nil {
+		return err
+	}
+	r.records++
+	if (r.MaxRecords > 0 && r.records >= r.MaxRecords) ||
+		(r.MaxBytes > 0 && atomic.LoadInt64(&r.written.n)+r.current.pendingBytes() >= r.MaxBytes) {
+		return r.Rotate()
+	}
+	return nil
+}
+
+// Flush flushes the datums written so far to the current file, which stays under its temporary name.
+func (r *RollingDataFileWriter) Flush() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Flush()
+}
+
+// Rotate completes the current file, if any. The next Write starts a new one.
+func (r *RollingDataFileWriter) Rotate() error {
+	if r.current == nil {
+		return nil
+	}
+	current, file, filename := r.current, r.file, r.filename
+	r.current, r.file, r.written = nil, nil, nil
+
+	mode := r.FileMode
+	if mode == 0 {
+		mode = 0644
+	}
+	err := current.Close()
+	if err == nil {
+		// temporary files are only readable by their owner
+		err = file.Chmod(mode)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	// unlike renaming, linking fails if a file was created under the name since the file was opened
+	for {
+		err := os.Link(file.Name(), filename)
+		if err == nil {
+			break
+		} else if !os.IsExist(err) {
+			return err
+		}
+		if filename, err = r.nextName(); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(file.Name()); err != nil {
+		return err
+	}
+	if r.OnRotate != nil {
+		return r.OnRotate(filename)
+	}
+	return nil
+}
+
+// Close completes the current file.
+func (r *RollingDataFileWriter) Close() error {
+	return r.Rotate()
+}
+
+// nextName returns the next name of the pattern which isn't taken by an existing file.
+func (r *RollingDataFileWriter) nextName() (string, error) {
+	for {
+		filename := fmt.Sprintf(r.pattern, r.seq)
+		r.seq++
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return filename, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
+
+// open starts a new file under a temporary name.
+func (r *RollingDataFileWriter) open() error {
+	filename, err := r.nextName()
+	if err != nil {
+		return err
+	}
+	r.filename = filename
+	dir, name := filepath.Split(r.filename)
+	if name == "" {
+		return errors.New("RollingDataFileWriter: the pattern must name files")
+	}
+	file, err := ioutil.TempFile(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	written := &countingWriter{w: file}
+	current, err := NewDataFileWriter(written, r.schema, NewDatumWriter(r.schema), r.opts...)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	r.current, r.file, r.written = current, file, written
+	r.opened, r.records = r.now(), 0
+	return nil
+}
+
+// countingWriter counts the bytes written, which may be written by the compression workers of a DataFileWriter.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,129 @@
+package avro
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readRollingFiles(t *testing.T, names []string) []int64 {
+	var values []int64
+	for _, name := range names {
+		encoded, err := ioutil.ReadFile(name)
+		assert(t, err, nil)
+		values = append(values, readTestDataFile(t, encoded)...)
+	}
+	return values
+}
+
+func TestRollingDataFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	// an existing file is skipped
+	assert(t, ioutil.WriteFile(filepath.Join(dir, "events-001.avro"), nil, 0644), nil)
+
+	schema := MustParseSchema(primitiveSchemaRaw)
+	w := NewRollingDataFileWriter(filepath.Join(dir, "events-%03d.avro"), schema, WithCodec("deflate"))
+	w.MaxRecords = 100
+	var rotated []string
+	w.OnRotate = func(filename string) error {
+		rotated = append(rotated, filename)
+		return nil
+	}
+	for i := 0; i < 250; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i)}), nil)
+		if i == 150 {
+			// only complete files and the temporary one
+			names, err := filepath.Glob(filepath.Join(dir, "*"))
+			assert(t, err, nil)
+			assert(t, len(names), 3)
+			tmp, err := filepath.Glob(filepath.Join(dir, ".events-002.avro.*.tmp"))
+			assert(t, err, nil)
+			assert(t, len(tmp), 1)
+		}
+	}
+	assert(t, w.Close(), nil)
+	assert(t, w.Close(), nil)
+
+	expected := []string{
+		filepath.Join(dir, "events-000.avro"),
+		filepath.Join(dir, "events-002.avro"),
+		filepath.Join(dir, "events-003.avro"),
+	}
+	assert(t, rotated, expected)
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert(t, err, nil)
+	assert(t, len(names), 4)
+
+	values := readRollingFiles(t, expected)
+	assert(t, len(values), 250)
+	for i, value := range values {
+		if value != int64(i) {
+			t.Fatalf("expected %d, got %d", i, value)
+		}
+	}
+}
+
+func TestRollingDataFileWriter_files(t *testing.T) {
+	dir := t.TempDir()
+	schema := MustParseSchema(primitiveSchemaRaw)
+	w := NewRollingDataFileWriter(filepath.Join(dir, "%d.avro"), schema)
+	assert(t, w.Write(&primitive{LongField: 1}), nil)
+	// a file created under the name while it is written is kept
+	assert(t, ioutil.WriteFile(filepath.Join(dir, "0.avro"), []byte("taken"), 0644), nil)
+	assert(t, w.Rotate(), nil)
+	taken, err := ioutil.ReadFile(filepath.Join(dir, "0.avro"))
+	assert(t, err, nil)
+	assert(t, string(taken), "taken")
+	assert(t, readRollingFiles(t, []string{filepath.Join(dir, "1.avro")}), []int64{1})
+	info, err := os.Stat(filepath.Join(dir, "1.avro"))
+	assert(t, err, nil)
+	assert(t, info.Mode().Perm(), os.FileMode(0644))
+
+	w.FileMode = 0640
+	assert(t, w.Write(&primitive{LongField: 2}), nil)
+	assert(t, w.Close(), nil)
+	info, err = os.Stat(filepath.Join(dir, "2.avro"))
+	assert(t, err, nil)
+	assert(t, info.Mode().Perm(), os.FileMode(0640))
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert(t, err, nil)
+	assert(t, len(names), 3)
+}
+
+func TestRollingDataFileWriter_bytesAndAge(t *testing.T) {
+	dir := t.TempDir()
+	schema := MustParseSchema(primitiveSchemaRaw)
+	w := NewRollingDataFileWriter(filepath.Join(dir, "%d.avro"), schema, WithBlockCount(10), WithCompressionWorkers(2))
+	w.MaxBytes = 2000
+	for i := 0; i < 200; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, w.Close(), nil)
+	names, err := filepath.Glob(filepath.Join(dir, "*.avro"))
+	assert(t, err, nil)
+	assert(t, len(names) > 2, true)
+	for _, name := range names {
+		info, err := os.Stat(name)
+		assert(t, err, nil)
+		// blocks queued for compression count too, so files only exceed MaxBytes by the last datum and the end
+		assert(t, info.Size() < 2100, true)
+	}
+
+	dir = t.TempDir()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w = NewRollingDataFileWriter(filepath.Join(dir, "%d.avro"), schema)
+	w.now = func() time.Time { return now }
+	w.MaxAge = time.Minute
+	for i := 0; i < 10; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i)}), nil)
+		now = now.Add(25 * time.Second)
+	}
+	assert(t, w.Close(), nil)
+	var counts []int
+	for _, name := range []string{"0.avro", "1.avro", "2.avro", "3.avro"} {
+		counts = append(counts, len(readRollingFiles(t, []string{filepath.Join(dir, name)})))
+	}
+	assert(t, counts, []int{3, 3, 3, 1})
+}
@@ -2,7 +2,13 @@ package avro
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -47,7 +53,7 @@ func TestDataFileWriter(t *testing.T) {
 	assert(t, len(encoded), 1145)
 
 	// now make sure we can decode again
-	dfr, err := newDataFileReader(bytes.NewReader(encoded))
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -60,6 +66,63 @@ func TestDataFileWriter(t *testing.T) {
 	assert(t, p.LongField, int64(1))
 }
 
+func TestDataFileMetadata(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	write := func(meta map[string]string) []byte {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), WithCodec("deflate"))
+		assert(t, err, nil)
+		for key, value := range meta {
+			assert(t, dfw.SetMetaString(key, value), nil)
+		}
+		assert(t, dfw.SetMeta("avro.codec", []byte("null")) != nil, true)
+		assert(t, dfw.Write(&primitive{LongField: 1}), nil)
+		assert(t, dfw.SetMeta("late", nil) != nil, true)
+		assert(t, dfw.Close(), nil)
+		return buf.Bytes()
+	}
+
+	encoded := write(map[string]string{"producer": "test", "lineage": "a/b"})
+	dfr, err := newDataFileReader(bytes.NewReader(encoded), nil)
+	assert(t, err, nil)
+	assert(t, string(dfr.GetMeta("producer")), "test")
+	assert(t, dfr.GetMeta("missing"), []byte(nil))
+	assert(t, len(dfr.Metadata()), 4)
+	assert(t, string(dfr.Metadata()["avro.codec"]), "deflate")
+	assert(t, dfr.Schema().String(), schema.String())
+	var p primitive
+	assert(t, dfr.Next(&p), nil)
+	assert(t, p.LongField, int64(1))
+
+	// the first flush writes the header, even without datums
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, buf.Len(), 0)
+	assert(t, dfw.SetMetaString("producer", "test"), nil)
+	assert(t, dfw.Flush(), nil)
+	assert(t, dfw.SetMetaString("late", "") != nil, true)
+	empty, err := newDataFileReader(bytes.NewReader(buf.Bytes()), nil)
+	assert(t, err, nil)
+	assert(t, string(empty.GetMeta("producer")), "test")
+	assert(t, empty.HasNext(), false)
+	assert(t, empty.Err(), nil)
+	empty, err = newDataFileReader(bytes.NewReader(buf.Bytes()), nil)
+	assert(t, err, nil)
+	assert(t, empty.DecodeConcurrently(2, func() interface{} { return &primitive{} }), nil)
+	assert(t, empty.HasNext(), false)
+	assert(t, empty.Err(), nil)
+	assert(t, dfw.Write(&primitive{LongField: 1}), nil)
+	assert(t, dfw.Close(), nil)
+	assert(t, readTestDataFile(t, buf.Bytes()), []int64{1})
+
+	// every file gets its own sync marker
+	other, err := newDataFileReader(bytes.NewReader(write(nil)), nil)
+	assert(t, err, nil)
+	assert(t, len(dfr.header.Sync), 16)
+	assert(t, bytes.Equal(dfr.header.Sync, other.header.Sync), false)
+}
+
 func TestDataFileReader_deflate(t *testing.T) {
 	r, err := NewDataFileReader("test/complex7.deflate.avro")
 	if err != nil {
@@ -76,6 +139,16 @@ func TestDataFileReader_null(t *testing.T) {
 	testComplex7(t, r)
 }
 
+func TestDataFileReader_codecs(t *testing.T) {
+	for _, codec := range []string{"zstandard", "bzip2", "xz"} {
+		r, err := NewDataFileReader("test/complex7." + codec + ".avro")
+		if err != nil {
+			t.Fatal(err)
+		}
+		testComplex7(t, r)
+	}
+}
+
 func testComplex7(t *testing.T, reader *DataFileReader) {
 	inputs := []struct {
 		n    int
@@ -103,3 +176,522 @@ func testComplex7(t *testing.T, reader *DataFileReader) {
 	assert(t, reader.Err(), nil)
 	assert(t, reader.err, io.EOF) // underlying error is EOF
 }
+
+// limitedWriter fails once more than n bytes have been written.
+type limitedWriter struct {
+	n   int
+	buf bytes.Buffer
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.n {
+		return 0, errDiskFull
+	}
+	return w.buf.Write(p)
+}
+
+func TestDataFileWriterErrors(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+
+	// the header is written with the first block
+	dfw, err := NewDataFileWriter(&limitedWriter{n: 100}, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, dfw.Write(&primitive{}), nil)
+	assert(t, dfw.Flush(), errDiskFull)
+
+	output := &limitedWriter{n: 900}
+	dfw, err = NewDataFileWriter(output, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+
+	// a datum which fails to encode is left out
+	assert(t, dfw.Write(&struct{ LongField int64 }{}) != nil, true)
+	assert(t, dfw.Write(&primitive{LongField: 1}), nil)
+	assert(t, dfw.blockCount, int64(1))
+	assert(t, dfw.Flush(), nil)
+
+	assert(t, dfw.Write(&primitive{LongField: 2}), nil)
+	assert(t, dfw.Flush(), errDiskFull)
+	assert(t, dfw.Err(), errDiskFull)
+	assert(t, dfw.Write(&primitive{LongField: 3}), errDiskFull)
+	assert(t, dfw.Close(), errDiskFull)
+}
+
+// readerAtOnly hides every method but ReadAt of a reader.
+type readerAtOnly struct {
+	r io.ReaderAt
+}
+
+func (r readerAtOnly) ReadAt(p []byte, off int64) (int, error) {
+	return r.r.ReadAt(p, off)
+}
+
+func (r readerAtOnly) Read(p []byte) (int, error) {
+	return 0, errors.New("Read should not be called")
+}
+
+func TestDataFileReaderSeekToBlock(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000) // blocks of 100
+	for _, input := range []io.Reader{bytes.NewReader(encoded), readerAtOnly{bytes.NewReader(encoded)}} {
+		dfr, err := NewDataFileReaderFrom(input)
+		assert(t, err, nil)
+
+		var offsets []int64
+		var p primitive
+		for dfr.HasNext() {
+			if len(offsets) == 0 || offsets[len(offsets)-1] != dfr.Tell() {
+				offsets = append(offsets, dfr.Tell())
+			}
+			assert(t, dfr.Next(&p), nil)
+		}
+		assert(t, len(offsets), 10)
+		assert(t, dfr.Tell(), int64(len(encoded)))
+
+		assert(t, dfr.SeekToBlock(offsets[3]), nil)
+		assert(t, dfr.Tell(), offsets[3])
+		assert(t, dfr.Next(&p), nil)
+		assert(t, p.LongField, int64(300))
+
+		// sync moves to the block after the next marker
+		assert(t, dfr.Sync(offsets[3]), nil)
+		assert(t, dfr.Tell(), offsets[4])
+		assert(t, dfr.Sync(offsets[3]-containerSyncSize), nil)
+		assert(t, dfr.Tell(), offsets[3])
+		assert(t, dfr.Sync(0), nil)
+		assert(t, dfr.Tell(), offsets[0])
+		assert(t, dfr.Sync(offsets[9]+1), nil)
+		assert(t, dfr.HasNext(), false)
+		assert(t, dfr.Err(), nil)
+		assert(t, dfr.Sync(int64(len(encoded)-5)), nil)
+		assert(t, dfr.Tell(), int64(len(encoded)))
+		assert(t, dfr.PastSync(int64(len(encoded)-5)), true)
+		assert(t, dfr.HasNext(), false)
+	}
+
+	dfr, err := NewDataFileReaderFrom(struct{ io.Reader }{bytes.NewReader(encoded)})
+	assert(t, err, nil)
+	assert(t, dfr.SeekToBlock(0) != nil, true)
+	assert(t, dfr.Sync(0) != nil, true)
+}
+
+func TestDataFileReaderSplits(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000)
+	for _, splits := range []int{1, 3, 7, 50} {
+		var values []int64
+		size := int64(len(encoded)/splits + 1)
+		for start := int64(0); start < int64(len(encoded)); start += size {
+			dfr, err := NewDataFileReaderFrom(bytes.NewReader(encoded))
+			assert(t, err, nil)
+			assert(t, dfr.Sync(start), nil)
+			for dfr.HasNext() && !dfr.PastSync(start+size) {
+				var p primitive
+				assert(t, dfr.Next(&p), nil)
+				values = append(values, p.LongField)
+			}
+			assert(t, dfr.Err(), nil)
+		}
+		assert(t, len(values), 1000)
+		for i, v := range values {
+			if v != int64(i) {
+				t.Fatalf("%d splits: expected %d at %d, found %d", splits, i, i, v)
+			}
+		}
+	}
+}
+
+func TestAppendDataFileWriter(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	f, err := os.Create(filepath.Join(t.TempDir(), "append.avro"))
+	assert(t, err, nil)
+	defer f.Close()
+
+	// an empty file is started with the given schema
+	_, err = AppendDataFileWriter(f)
+	assert(t, err != nil, true)
+	n := int64(0)
+	for i := 0; i < 3; i++ {
+		dfw, err := AppendDataFileWriter(f, WithSchema(schema), WithCodec("deflate"))
+		assert(t, err, nil)
+		assert(t, dfw.SetMetaString("late", "") != nil, i > 0)
+		for j := 0; j < 5; j++ {
+			assert(t, dfw.Write(&primitive{LongField: n}), nil)
+			n++
+		}
+		assert(t, dfw.Close(), nil)
+	}
+	// closing without writing leaves the file as it is
+	dfw, err := AppendDataFileWriter(f)
+	assert(t, err, nil)
+	assert(t, dfw.Close(), nil)
+
+	_, err = AppendDataFileWriter(f, WithCodec("snappy"))
+	assert(t, err != nil, true)
+	_, err = AppendDataFileWriter(f, WithSchema(MustParseSchema(`"string"`)))
+	assert(t, err != nil, true)
+
+	_, err = f.Seek(0, io.SeekStart)
+	assert(t, err, nil)
+	dfr, err := NewDataFileReaderFrom(f)
+	assert(t, err, nil)
+	assert(t, string(dfr.GetMeta("avro.codec")), "deflate")
+	// there is a single empty block at the end
+	var counts []int64
+	for {
+		counts = append(counts, dfr.block.NumEntries)
+		if dfr.NextBlock() != nil {
+			break
+		}
+	}
+	assert(t, dfr.Err(), nil)
+	assert(t, counts, []int64{5, 5, 5, 0})
+
+	assert(t, dfr.SeekToBlock(0) != nil, true)
+	assert(t, dfr.Sync(0), nil)
+	var values []int64
+	for dfr.HasNext() {
+		var p primitive
+		assert(t, dfr.Next(&p), nil)
+		values = append(values, p.LongField)
+	}
+	assert(t, values, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14})
+
+	// empty blocks compressed differently are recognized too
+	encoded := writeTestDataFile(t, 5, WithCodec("deflate"))
+	header, err := ReadDataFileHeader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	trailer := append([]byte{0, 4, 0x03, 0x00}, header.Sync...)
+	assert(t, bytes.HasSuffix(encoded, trailer), true)
+	stored := append([]byte{0, 10, 0x01, 0x00, 0x00, 0xff, 0xff}, header.Sync...) // an empty stored deflate block
+	encoded = append(encoded[:len(encoded)-len(trailer)], stored...)
+	f, err = os.Create(filepath.Join(t.TempDir(), "stored.avro"))
+	assert(t, err, nil)
+	defer f.Close()
+	_, err = f.Write(encoded)
+	assert(t, err, nil)
+	dfw, err = AppendDataFileWriter(f)
+	assert(t, err, nil)
+	assert(t, dfw.Write(&primitive{LongField: 5}), nil)
+	assert(t, dfw.Close(), nil)
+	_, err = f.Seek(0, io.SeekStart)
+	assert(t, err, nil)
+	appended, err := ioutil.ReadAll(f)
+	assert(t, err, nil)
+	assert(t, bytes.Contains(appended, stored), false)
+	assert(t, readTestDataFile(t, appended), []int64{0, 1, 2, 3, 4, 5})
+
+	other, err := os.Create(filepath.Join(t.TempDir(), "other.txt"))
+	assert(t, err, nil)
+	defer other.Close()
+	_, err = other.WriteString("not an avro file, but long enough to have a header")
+	assert(t, err, nil)
+	_, err = AppendDataFileWriter(other, WithSchema(schema))
+	assert(t, err, ErrNotAvroFile)
+}
+
+func TestDataFileWriterAutoFlush(t *testing.T) {
+	blockCounts := func(encoded []byte) []int64 {
+		dfr, err := NewDataFileReaderFrom(bytes.NewReader(encoded))
+		assert(t, err, nil)
+		var counts []int64
+		for {
+			counts = append(counts, dfr.block.NumEntries)
+			if dfr.NextBlock() != nil {
+				break
+			}
+		}
+		assert(t, dfr.Err(), nil)
+		return counts
+	}
+	write := func(n int, opts ...DataFileWriterOption) []byte {
+		schema := MustParseSchema(primitiveSchemaRaw)
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), opts...)
+		assert(t, err, nil)
+		for i := 0; i < n; i++ {
+			assert(t, dfw.Write(&primitive{LongField: int64(i), BytesField: make([]byte, 1000)}), nil)
+		}
+		assert(t, dfw.Close(), nil)
+		return buf.Bytes()
+	}
+
+	// each datum is a little over 1000 bytes
+	assert(t, blockCounts(write(150)), []int64{63, 63, 24, 0})
+	assert(t, blockCounts(write(150, WithBlockSize(10000))), []int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 0})
+	assert(t, blockCounts(write(150, WithBlockSize(0))), []int64{150, 0})
+	assert(t, blockCounts(write(150, WithBlockCount(100))), []int64{63, 63, 24, 0})
+	assert(t, blockCounts(write(150, WithBlockSize(0), WithBlockCount(100))), []int64{100, 50, 0})
+	assert(t, blockCounts(write(150, WithBlockCount(7), WithCodec("snappy")))[:3], []int64{7, 7, 7})
+}
+
+func TestDataFileReaderSkipCorruptBlocks(t *testing.T) {
+	// blocks of 100 datums at offsets[0] to offsets[4], followed by the empty block at offsets[5]
+	valid := writeTestDataFile(t, 500)
+	dfr, err := NewDataFileReaderFrom(bytes.NewReader(valid))
+	assert(t, err, nil)
+	offsets := []int64{dfr.Tell()}
+	for dfr.NextBlock() == nil {
+		offsets = append(offsets, dfr.Tell())
+	}
+	assert(t, len(offsets), 6)
+
+	tests := []struct {
+		name    string
+		corrupt func(encoded []byte) []byte
+		values  int
+		skipped []CorruptData
+	}{
+		{
+			name: "sync marker",
+			corrupt: func(encoded []byte) []byte {
+				encoded[offsets[2]-1] ^= 0xff
+				return encoded
+			},
+			values:  400,
+			skipped: []CorruptData{{Offset: offsets[2] - containerSyncSize, Bytes: offsets[3] - offsets[2] + containerSyncSize, Blocks: 1}},
+		},
+		{
+			name: "block size",
+			corrupt: func(encoded []byte) []byte {
+				encoded[offsets[1]+2] = 0x7f
+				return encoded
+			},
+			values:  400,
+			skipped: []CorruptData{{Offset: offsets[1], Bytes: offsets[2] - offsets[1], Blocks: 1}},
+		},
+		{
+			name: "consecutive blocks",
+			corrupt: func(encoded []byte) []byte {
+				encoded[offsets[1]+2] = 0x7f
+				encoded[offsets[2]+2] = 0x7f
+				return encoded
+			},
+			values:  300,
+			skipped: []CorruptData{{Offset: offsets[1], Bytes: offsets[3] - offsets[1], Blocks: 2}},
+		},
+		{
+			name: "datum",
+			corrupt: func(encoded []byte) []byte {
+				encoded[offsets[3]] += 2 // 101 datums
+				return encoded
+			},
+			values:  500,
+			skipped: []CorruptData{{Offset: offsets[3], Bytes: offsets[4] - offsets[3], Blocks: 1}},
+		},
+		{
+			name: "truncated",
+			corrupt: func(encoded []byte) []byte {
+				return encoded[:offsets[4]+50]
+			},
+			values:  400,
+			skipped: []CorruptData{{Offset: offsets[4], Bytes: 50, Blocks: 1}},
+		},
+	}
+	for _, test := range tests {
+		for _, seekable := range []bool{true, false} {
+			encoded := test.corrupt(append([]byte(nil), valid...))
+			var input io.Reader = bytes.NewReader(encoded)
+			if !seekable {
+				input = struct{ io.Reader }{input}
+			}
+			dfr, err := NewDataFileReaderFrom(input)
+			assert(t, err, nil)
+			var skipped []CorruptData
+			dfr.SkipCorruptBlocks(func(c CorruptData) {
+				assert(t, c.Err != nil, true)
+				c.Err = nil
+				skipped = append(skipped, c)
+			})
+			values := 0
+			for dfr.HasNext() {
+				var p primitive
+				assert(t, dfr.Next(&p), nil)
+				values++
+			}
+			if values != test.values || dfr.Err() != nil || !reflect.DeepEqual(skipped, test.skipped) {
+				t.Errorf("%s (seekable %v): read %d datums with error %v, skipped %+v", test.name, seekable, values, dfr.Err(), skipped)
+			}
+		}
+	}
+
+	// without recovery the reader stops
+	encoded := tests[1].corrupt(append([]byte(nil), valid...))
+	dfr, err = NewDataFileReaderFrom(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	values := 0
+	for dfr.HasNext() {
+		var p primitive
+		assert(t, dfr.Next(&p), nil)
+		values++
+	}
+	assert(t, values, 100)
+	assert(t, dfr.Err().Error(), "Block size invalid or too large: -64")
+}
+
+func TestNewDataFileReaderWithSchema(t *testing.T) {
+	encoded := writeTestDataFile(t, 250, WithCodec("deflate"))
+	readerSchema := MustParseSchema(`{"type":"record","name":"Primitive","namespace":"example.avro","fields":[
+		{"name":"longField","type":"long"},
+		{"name":"stringField","type":"string"},
+		{"name":"label","type":"string","default":"none"}]}`)
+
+	type primitiveV2 struct {
+		LongField   int64
+		StringField string
+		Label       string
+	}
+	dfr, err := NewDataFileReaderWithSchema(bytes.NewReader(encoded), readerSchema)
+	assert(t, err, nil)
+	assert(t, RabinFingerprint(dfr.Schema()), RabinFingerprint(MustParseSchema(primitiveSchemaRaw)))
+	var values []primitiveV2
+	for dfr.HasNext() {
+		var p primitiveV2
+		assert(t, dfr.Next(&p), nil)
+		values = append(values, p)
+	}
+	assert(t, dfr.Err(), nil)
+	assert(t, len(values), 250)
+	assert(t, values[249], primitiveV2{LongField: 249, StringField: "a string that compresses well", Label: "none"})
+
+	dfr, err = NewDataFileReaderWithSchema(bytes.NewReader(encoded), readerSchema)
+	assert(t, err, nil)
+	record := NewGenericRecord(readerSchema)
+	assert(t, dfr.Next(record), nil)
+	assert(t, record.Get("label"), "none")
+	assert(t, record.Get("booleanField"), nil)
+
+	_, err = NewDataFileReaderWithSchema(bytes.NewReader(encoded), MustParseSchema(`{"type":"record","name":"Primitive","namespace":"example.avro","fields":[
+		{"name":"missing","type":"string"}]}`))
+	assert(t, err != nil, true)
+}
+
+func TestReadDataFileHeader(t *testing.T) {
+	encoded := writeTestDataFile(t, 250, WithCodec("deflate"))
+	dfr, err := NewDataFileReaderFrom(bytes.NewReader(encoded))
+	assert(t, err, nil)
+
+	r := bytes.NewReader(encoded)
+	header, err := ReadDataFileHeader(r)
+	assert(t, err, nil)
+	assert(t, RabinFingerprint(header.Schema), RabinFingerprint(dfr.Schema()))
+	assert(t, header.Codec, "deflate")
+	assert(t, header.Sync, dfr.header.Sync)
+	assert(t, header.Meta, dfr.Metadata())
+	assert(t, header.DataOffset, dfr.Tell())
+	assert(t, r.Len(), len(encoded)-int(header.DataOffset))
+
+	header, err = ReadDataFileHeader(bytes.NewReader(writeTestDataFile(t, 0)))
+	assert(t, err, nil)
+	assert(t, header.Codec, "null")
+
+	// unknown codecs are fine
+	RegisterCodec("test-unknown", func(int) (Codec, error) { return nullCodec{}, nil })
+	unknown := writeTestDataFile(t, 1, WithCodec("test-unknown"))
+	codecsMu.Lock()
+	delete(codecs, "test-unknown")
+	codecsMu.Unlock()
+	header, err = ReadDataFileHeader(bytes.NewReader(unknown))
+	assert(t, err, nil)
+	assert(t, header.Codec, "test-unknown")
+
+	_, err = ReadDataFileHeader(bytes.NewReader([]byte("not an avro file")))
+	assert(t, err, ErrNotAvroFile)
+	_, err = ReadDataFileHeader(bytes.NewReader(encoded[:20]))
+	assert(t, err != nil, true)
+}
+
+func TestDataFileWriterAppendBlocksFrom(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	write := func(from, to int64, opts ...DataFileWriterOption) []byte {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), append(opts, WithBlockCount(40))...)
+		assert(t, err, nil)
+		for i := from; i < to; i++ {
+			assert(t, dfw.Write(&primitive{LongField: i}), nil)
+		}
+		assert(t, dfw.Close(), nil)
+		return buf.Bytes()
+	}
+	sources := [][]byte{
+		write(0, 100, WithCodec("deflate")),
+		write(100, 200, WithCodec("snappy")),
+		write(200, 300, WithCodec("deflate"), WithCompressionLevel(1)),
+	}
+
+	for _, opts := range [][]DataFileWriterOption{nil, {WithCompressionWorkers(2)}} {
+		buf := &bytes.Buffer{}
+		dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter(), append(opts, WithCodec("deflate"))...)
+		assert(t, err, nil)
+		var copied [][]byte
+		for i, source := range sources {
+			// datums written in between go in their own block
+			assert(t, dfw.Write(&primitive{LongField: int64(-i - 1)}), nil)
+			dfr, err := NewDataFileReaderFrom(bytes.NewReader(source))
+			assert(t, err, nil)
+			if i != 1 {
+				copied = append(copied, dfr.block.raw)
+			}
+			assert(t, dfw.AppendBlocksFrom(dfr), nil)
+			assert(t, dfr.HasNext(), false)
+			assert(t, dfr.Err(), nil)
+		}
+		assert(t, dfw.Close(), nil)
+
+		values := readTestDataFile(t, buf.Bytes())
+		assert(t, len(values), 303)
+		for i, value := range values {
+			expected := int64(i - i/101 - 1)
+			if i%101 == 0 {
+				expected = int64(-i/101 - 1)
+			}
+			if value != expected {
+				t.Fatalf("expected %d at %d, got %d", expected, i, value)
+			}
+		}
+		// deflate blocks are copied as they are
+		for _, block := range copied {
+			assert(t, bytes.Contains(buf.Bytes(), block), true)
+		}
+	}
+}
+
+func TestDataFileWriterAppendBlocksFrom_errors(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	dfw, err := NewDataFileWriter(&bytes.Buffer{}, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+
+	dfr, err := NewDataFileReaderFrom(bytes.NewReader(writeTestDataFile(t, 250)))
+	assert(t, err, nil)
+	var p primitive
+	assert(t, dfr.Next(&p), nil)
+	assert(t, dfw.AppendBlocksFrom(dfr).Error(), "DataFileWriter: can't append a partially read block")
+
+	other := MustParseSchema(`{"type":"record","name":"Other","fields":[{"name":"a","type":"int"}]}`)
+	buf := &bytes.Buffer{}
+	otherWriter, err := NewDataFileWriter(buf, other, NewGenericDatumWriter())
+	assert(t, err, nil)
+	assert(t, otherWriter.Close(), nil)
+	dfr, err = NewDataFileReaderFrom(bytes.NewReader(buf.Bytes()))
+	assert(t, err, nil)
+	assert(t, dfw.AppendBlocksFrom(dfr).Error(), "DataFileWriter: schema doesn't match the schema of the file")
+}
+
+func TestDataFileReaderCorruptBlockSize(t *testing.T) {
+	encoded := writeTestDataFile(t, 10)
+	header, err := ReadDataFileHeader(bytes.NewReader(encoded))
+	assert(t, err, nil)
+	// a block of 2e9 bytes in a small file fails without allocating them
+	corrupt := bytes.NewBuffer(append([]byte(nil), encoded[:header.DataOffset]...))
+	enc := NewBinaryEncoder(corrupt)
+	enc.WriteLong(10)
+	enc.WriteLong(2000000000)
+	corrupt.Write(make([]byte, 1000))
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	before := stats.TotalAlloc
+	_, err = NewDataFileReaderFrom(bytes.NewReader(corrupt.Bytes()))
+	assert(t, err, ErrUnexpectedEOF)
+	runtime.ReadMemStats(&stats)
+	assert(t, stats.TotalAlloc-before < 1<<20, true)
+}
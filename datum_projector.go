@@ -26,6 +26,9 @@ func (reader *DatumProjector) Read(target interface{}, dec Decoder) error {
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return errors.New("not applicable for non-pointer types or nil")
 	}
+	if err := decoderStartDatum(dec); err != nil {
+		return err
+	}
 	return reader.projector.Project(rv, dec)
 }
 
@@ -43,14 +46,7 @@ func (p *defaultProjector) Project(target reflect.Value, dec Decoder) error {
 	if v, err := p.Unwrap(dec); err != nil {
 		return err
 	} else if v != nil {
-		if target.Kind() == reflect.Ptr {
-			target.Set(reflect.New(target.Type().Elem()))
-			target = target.Elem()
-		}
-		rv := reflect.ValueOf(v)
-		if rv.IsValid() {
-			target.Set(rv)
-		}
+		setProjected(target, v)
 	}
 	return nil
 }
@@ -59,43 +55,58 @@ func (p *defaultProjector) Unwrap(dec Decoder) (interface{}, error) {
 	return p.unwrap(dec)
 }
 
+//sets an unwrapped value on the target, allocating it first if it is a pointer
+func setProjected(target reflect.Value, v interface{}) {
+	if target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() {
+		target.Set(rv)
+	}
+}
+
 func newProjector(readerSchema, writerSchema Schema) (projector, error) {
 
 	if writerSchema.Type() == Union {
+		//each writer branch is resolved against the reader union or the reader schema itself,
+		//branches without a match only fail when they actually show up in the data
+		readerTypes := []Schema{readerSchema}
 		if readerSchema.Type() == Union {
-			writerUnionSchema := writerSchema.(*UnionSchema)
-			variants := make(map[int32]projector)
-			for i, t := range writerUnionSchema.Types {
-				if p, err := newProjector(t, t); err != nil {
-					return nil, err
-				} else {
-					variants[int32(i)] = p
-				}
-			}
-			return newUnionProjector(variants)
-		} else {
-			for i, t := range writerSchema.(*UnionSchema).Types {
-				if t.Type() == readerSchema.Type() && t.GetName() == readerSchema.GetName() {
-					variants := make(map[int32]projector)
-					if p, err := newProjector(readerSchema, t); err != nil {
-						return nil, err
-					} else {
-						variants[int32(i)] = p
-					}
-					return newUnionProjector(variants)
+			readerTypes = readerSchema.(*UnionSchema).Types
+		}
+		variants := make(map[int32]projector)
+		matched := false
+		for i, t := range writerSchema.(*UnionSchema).Types {
+			if p, err := newUnionBranchProjector(readerTypes, t); err != nil {
+				return nil, err
+			} else if p != nil {
+				variants[int32(i)] = p
+				matched = true
+			} else {
+				variants[int32(i)] = &errorProjector{
+					fmt.Errorf("writer union branch %d (%v) does not match the reader schema", i, t.GetName()),
 				}
 			}
+		}
+		if !matched && readerSchema.Type() != Union {
 			return nil, fmt.Errorf("writer Union does not match reader schema: %v", readerSchema)
 		}
+		return newUnionProjector(variants)
 	} else if readerSchema.Type() == Union {
-		for _, t := range readerSchema.(*UnionSchema).Types {
-			if p, err := newProjector(t, writerSchema); err == nil {
-				return p, nil
-			}
+		if p, err := newUnionBranchProjector(readerSchema.(*UnionSchema).Types, writerSchema); err != nil {
+			return nil, err
+		} else if p != nil {
+			return p, nil
 		}
 		return nil, fmt.Errorf("reader Union does not contain the writer schema: %v", writerSchema)
 	}
 
+	if isLogical(readerSchema) {
+		return newLogicalProjector(readerSchema, writerSchema)
+	}
+
 	switch readerSchema.Type() {
 	case Null:
 		switch writerSchema.Type() {
@@ -294,6 +305,43 @@ func newProjector(readerSchema, writerSchema Schema) (projector, error) {
 	}
 }
 
+func newLogicalProjector(readerSchema, writerSchema Schema) (projector, error) {
+	if p, err := newProjector(physicalSchema(readerSchema), writerSchema); err != nil {
+		return nil, err
+	} else {
+		return &logicalProjector{
+			schema:   readerSchema,
+			physical: p,
+		}, nil
+	}
+}
+
+//logical projector maps values projected onto the underlying type of a logical schema to their native go values
+type logicalProjector struct {
+	schema   Schema
+	physical projector
+}
+
+func (p *logicalProjector) Unwrap(dec Decoder) (interface{}, error) {
+	if v, err := p.physical.Unwrap(dec); err != nil {
+		return nil, err
+	} else {
+		return decodeLogical(p.schema, v)
+	}
+}
+
+func (p *logicalProjector) Project(target reflect.Value, dec Decoder) error {
+	if !acceptsLogical(p.schema, target.Type()) {
+		return p.physical.Project(target, dec)
+	}
+	if v, err := p.Unwrap(dec); err != nil {
+		return err
+	} else {
+		target.Set(logicalValue(v, target.Type()))
+	}
+	return nil
+}
+
 func newEnumProjector(readerSchema, writerSchema *EnumSchema) (projector, error) {
 	return &enumProjector{
 		readerSchema: readerSchema,
@@ -338,6 +386,49 @@ func (p *enumProjector) Project(target reflect.Value, dec Decoder) error {
 	return nil
 }
 
+//union branch projector resolves a writer schema against the first reader branch of the same type,
+//or if there is none, the first reader branch it can be promoted to. returns nil if no branch matches.
+func newUnionBranchProjector(readerTypes []Schema, writerSchema Schema) (projector, error) {
+	writer := resolvedSchema(writerSchema)
+	for _, t := range readerTypes {
+		reader := resolvedSchema(t)
+		if reader.Type() != writer.Type() {
+			continue
+		}
+		switch writer.Type() {
+		case Record, Enum, Fixed:
+			if unqualifiedName(reader.GetName()) != unqualifiedName(writer.GetName()) {
+				continue
+			}
+		}
+		return newProjector(t, writerSchema)
+	}
+	switch writer.Type() {
+	case Record, Enum, Fixed:
+		//named types are never promoted
+		return nil, nil
+	}
+	for _, t := range readerTypes {
+		if p, err := newProjector(t, writerSchema); err == nil {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+//error projector stands in for writer union branches that can't be read with the reader schema
+type errorProjector struct {
+	err error
+}
+
+func (p *errorProjector) Unwrap(dec Decoder) (interface{}, error) {
+	return nil, p.err
+}
+
+func (p *errorProjector) Project(target reflect.Value, dec Decoder) error {
+	return p.err
+}
+
 func newUnionProjector(variants map[int32]projector) (projector, error) {
 	return &unionProjector{
 		variants: variants,
@@ -535,6 +626,7 @@ func newRecordProjector(readerRecordSchema, writerRecordSchema *RecordSchema) (p
 	p := &RecordProjector{
 		defaultUnwrapperMap: make(map[string]interface{}, 0),
 		defaultIndexMap:     make(map[string]reflect.Value, 0),
+		defaultPhysicalMap:  make(map[string]reflect.Value, 0),
 		projectNameMap:      make([]string, len(writerRecordSchema.Fields)),
 		projectIndexMap:     make([]projector, len(writerRecordSchema.Fields)),
 	}
@@ -588,6 +680,14 @@ NextReaderField:
 			}
 			p.defaultUnwrapperMap[readerField.Name] = genericDefaultValue
 			p.defaultIndexMap[readerField.Name] = reflect.ValueOf(genericDefaultValue)
+			if isLogical(readerField.Type) {
+				//struct fields which don't hold the native value of a logical type get the underlying value
+				if physicalDefaultValue, ok, err := encodeLogical(readerField.Type, genericDefaultValue); err != nil {
+					return nil, err
+				} else if ok {
+					p.defaultPhysicalMap[readerField.Name] = reflect.ValueOf(physicalDefaultValue)
+				}
+			}
 		} else {
 			delete(p.defaultIndexMap, readerField.Name)
 		}
@@ -601,6 +701,7 @@ type RecordProjector struct {
 	writerRecordSchema  *RecordSchema
 	defaultUnwrapperMap map[string]interface{}
 	defaultIndexMap     map[string]reflect.Value
+	defaultPhysicalMap  map[string]reflect.Value
 	projectNameMap      []string
 	projectIndexMap     []projector
 }
@@ -664,11 +765,11 @@ func (p *RecordProjector) Project(target reflect.Value, dec Decoder) error {
 		if len(p.defaultIndexMap) > 0 {
 			for d := range p.defaultIndexMap {
 				if field := target.FieldByName(d); field.IsValid() {
-					field.Set(p.defaultIndexMap[d])
+					field.Set(p.defaultValue(d, field.Type()))
 				} else {
 					if field = target.FieldByName(strings.Title(d)); field.IsValid() && p.defaultIndexMap[d].IsValid() {
 						//default value is converted in case it is a type alias
-						field.Set(p.defaultIndexMap[d].Convert(field.Type()))
+						field.Set(p.defaultValue(d, field.Type()).Convert(field.Type()))
 					}
 				}
 			}
@@ -676,3 +777,12 @@ func (p *RecordProjector) Project(target reflect.Value, dec Decoder) error {
 	}
 	return nil
 }
+
+// defaultValue returns the default value of the named field suitable for a struct field of the given type
+func (p *RecordProjector) defaultValue(name string, t reflect.Type) reflect.Value {
+	value := p.defaultIndexMap[name]
+	if physical, ok := p.defaultPhysicalMap[name]; ok && !value.Type().ConvertibleTo(t) {
+		return physical
+	}
+	return value
+}
@@ -478,3 +478,70 @@ func TestProjections(t *testing.T) {
 
 
 }
+
+func TestUnionToUnionProjection(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "value", "type": ["null", "int", "string", {"type": "record", "name": "Point", "fields": [
+			{"name": "x", "type": "int"}
+		]}, "boolean"]}
+	]}`)
+	//reordered branches, int promoted to long, point gains a field and boolean is removed
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "value", "type": [{"type": "record", "name": "Point", "fields": [
+			{"name": "x", "type": "long"},
+			{"name": "y", "type": "long", "default": 7}
+		]}, "string", "null", "double", "long"]}
+	]}`)
+
+	projector, err := NewDatumProjector(readerSchema, writerSchema)
+	assert(t, err, nil)
+
+	write := func(value interface{}) []byte {
+		var buf bytes.Buffer
+		record := NewGenericRecord(writerSchema)
+		record.Set("value", value)
+		assert(t, NewDatumWriter(writerSchema).Write(record, NewBinaryEncoder(&buf)), nil)
+		return buf.Bytes()
+	}
+	read := func(data []byte) (interface{}, error) {
+		record := NewGenericRecord(readerSchema)
+		err := projector.Read(record, NewBinaryDecoder(data))
+		return record.Get("value"), err
+	}
+
+	value, err := read(write(int32(42)))
+	assert(t, err, nil)
+	//first matching branch in reader order wins: double comes before long
+	assert(t, value, float64(42))
+
+	value, err = read(write("hello"))
+	assert(t, err, nil)
+	assert(t, value, "hello")
+
+	value, err = read(write(nil))
+	assert(t, err, nil)
+	assert(t, value, nil)
+
+	point := NewGenericRecord(writerSchema.(*RecordSchema).Fields[0].Type.(*UnionSchema).Types[3])
+	point.Set("x", int32(3))
+	value, err = read(write(point))
+	assert(t, err, nil)
+	assert(t, value.(*GenericRecord).Get("x"), int64(3))
+	assert(t, value.(*GenericRecord).Get("y"), int64(7))
+
+	//the removed branch only fails when it is actually present in the data
+	_, err = read(write(true))
+	assert(t, err != nil, true)
+
+	//same for a non-union reader
+	projector, err = NewDatumProjector(
+		MustParseSchema(`{"type": "record", "name": "Rec", "fields": [{"name": "value", "type": "long"}]}`),
+		MustParseSchema(`{"type": "record", "name": "Rec", "fields": [{"name": "value", "type": ["null", "int"]}]}`))
+	assert(t, err, nil)
+	var long struct {
+		Value int64
+	}
+	assert(t, projector.Read(&long, NewBinaryDecoder([]byte{2, 84})), nil)
+	assert(t, long.Value, int64(42))
+	assert(t, projector.Read(&long, NewBinaryDecoder([]byte{0})) != nil, true)
+}
@@ -180,6 +180,9 @@ func (reader *SpecificDatumReader) SetSchema(schema Schema) DatumReader {
 // May return an error indicating a read failure.
 func (reader *SpecificDatumReader) Read(v interface{}, dec Decoder) error {
 	if reader, ok := v.(Unmarshaler); ok {
+		if err := decoderStartDatum(dec); err != nil {
+			return err
+		}
 		return reader.UnmarshalAvro(dec)
 	}
 
@@ -190,6 +193,9 @@ func (reader *SpecificDatumReader) Read(v interface{}, dec Decoder) error {
 	if reader.schema == nil {
 		return ErrSchemaNotSet
 	}
+	if err := decoderStartDatum(dec); err != nil {
+		return err
+	}
 	return reader.fillRecord(reader.schema, rv, dec)
 }
 
@@ -216,6 +222,10 @@ func (reader sDatumReader) findAndSet(v reflect.Value, field *SchemaField, dec D
 }
 
 func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	if isLogical(field) {
+		return reader.mapLogical(field, reflectField, dec)
+	}
+
 	switch field.Type() {
 	case Null:
 		return reflect.ValueOf(nil), nil
@@ -268,6 +278,20 @@ func (reader sDatumReader) mapPrimitive(readerFunc func() (interface{}, error))
 	return reflect.ValueOf(value), nil
 }
 
+func (reader sDatumReader) mapLogical(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	value, err := reader.readValue(physicalSchema(field), reflectField, dec)
+	if err != nil || !acceptsLogical(field, reflectField.Type()) {
+		// fields which can't hold the native value get the underlying value instead
+		return value, err
+	}
+
+	native, err := decodeLogical(field, value.Interface())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return logicalValue(native, reflectField.Type()), nil
+}
+
 func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 	arrayLength, err := dec.ReadArrayStart()
 	if err != nil {
@@ -491,6 +515,9 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 	if reader.schema == nil {
 		return ErrSchemaNotSet
 	}
+	if err := decoderStartDatum(dec); err != nil {
+		return err
+	}
 
 	//read the value
 	value, err := reader.readValue(reader.schema, dec)
@@ -502,6 +529,9 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 	// dereference the value if needed
 	if newValue.Kind() == reflect.Ptr {
 		newValue = newValue.Elem()
+	} else if !newValue.IsValid() {
+		// null datums
+		newValue = reflect.Zero(rv.Type())
 	}
 
 	//set the new value
@@ -536,6 +566,14 @@ func (reader *GenericDatumReader) findAndSet(record *GenericRecord, field *Schem
 }
 
 func (reader *GenericDatumReader) readValue(field Schema, dec Decoder) (interface{}, error) {
+	if isLogical(field) {
+		value, err := reader.readValue(physicalSchema(field), dec)
+		if err != nil {
+			return nil, err
+		}
+		return decodeLogical(field, value)
+	}
+
 	switch field.Type() {
 	case Null:
 		return nil, nil
@@ -92,7 +92,11 @@ func (writer *SpecificDatumWriter) SetSchema(schema Schema) DatumWriter {
 // May return an error indicating a write failure.
 func (writer *SpecificDatumWriter) Write(obj interface{}, enc Encoder) error {
 	if writer, ok := obj.(Marshaler); ok {
-		return writer.MarshalAvro(enc)
+		encoderStartDatum(enc)
+		if err := writer.MarshalAvro(enc); err != nil {
+			return err
+		}
+		return encoderErr(enc)
 	}
 
 	rv := reflect.ValueOf(obj)
@@ -101,10 +105,22 @@ func (writer *SpecificDatumWriter) Write(obj interface{}, enc Encoder) error {
 		return ErrSchemaNotSet
 	}
 
-	return writer.write(rv, enc, writer.schema)
+	encoderStartDatum(enc)
+	if err := writer.write(rv, enc, writer.schema); err != nil {
+		return err
+	}
+	return encoderErr(enc)
 }
 
 func (writer *SpecificDatumWriter) write(v reflect.Value, enc Encoder, s Schema) error {
+	if isLogical(s) && v.IsValid() && v.CanInterface() {
+		if value, ok, err := encodeLogical(s, v.Interface()); err != nil {
+			return err
+		} else if ok {
+			v = reflect.ValueOf(value)
+		}
+	}
+
 	switch s.Type() {
 	case Null:
 	case Boolean:
@@ -339,10 +355,22 @@ func (writer *GenericDatumWriter) SetSchema(schema Schema) DatumWriter {
 // Accepts a value to write and Encoder to write to.
 // May return an error indicating a write failure.
 func (writer *GenericDatumWriter) Write(obj interface{}, enc Encoder) error {
-	return writer.write(obj, enc, writer.schema)
+	encoderStartDatum(enc)
+	if err := writer.write(obj, enc, writer.schema); err != nil {
+		return err
+	}
+	return encoderErr(enc)
 }
 
 func (writer *GenericDatumWriter) write(v interface{}, enc Encoder, s Schema) error {
+	if isLogical(s) {
+		if value, ok, err := encodeLogical(s, v); err != nil {
+			return err
+		} else if ok {
+			v = value
+		}
+	}
+
 	switch s.Type() {
 	case Null:
 	case Boolean:
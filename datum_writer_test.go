@@ -607,3 +607,29 @@ var _Employee_schema, _Employee_schema_err = ParseSchema(`{
         }
     ]
 }`)
+
+func TestDatumWriterEncoderError(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "R", "fields": [
+		{"name": "a", "type": "long"},
+		{"name": "b", "type": "string"}
+	]}`)
+	output := &limitedWriter{n: 1}
+	enc := NewBinaryEncoder(output)
+	assert(t, NewDatumWriter(schema).Write(&struct {
+		A int64
+		B string
+	}{1, "b"}, enc), errDiskFull)
+	assert(t, enc.(ErrorEncoder).Err(), errDiskFull)
+	assert(t, output.buf.Bytes(), []byte{2})
+
+	// the error sticks, nothing is written after it
+	output.n = 100
+	enc.WriteLong(5)
+	assert(t, output.buf.Bytes(), []byte{2})
+
+	record := NewGenericRecord(schema)
+	record.Set("a", int64(1))
+	record.Set("b", "b")
+	assert(t, NewDatumWriter(schema).Write(record, enc), errDiskFull)
+	assert(t, NewDatumWriter(schema).Write(record, NewBinaryEncoder(output)), nil)
+}
@@ -58,6 +58,25 @@ type Decoder interface {
 	ReadFixed([]byte) error
 }
 
+// DatumDecoder is a Decoder which delimits datums, like JSONDecoder. The datum readers call StartDatum before reading
+// each datum, so datums read without calling the Decoder, like nulls, are consumed too.
+type DatumDecoder interface {
+	Decoder
+
+	// StartDatum starts reading a datum. Returns an error if it occurs, e.g. io.EOF after the last datum.
+	StartDatum() error
+}
+
+var _ DatumDecoder = (*JSONDecoder)(nil)
+
+// decoderStartDatum starts a datum of a DatumDecoder.
+func decoderStartDatum(dec Decoder) error {
+	if d, ok := dec.(DatumDecoder); ok {
+		return d.StartDatum()
+	}
+	return nil
+}
+
 const maxIntBufSize = 5
 const maxLongBufSize = 10
 
@@ -59,6 +59,18 @@ Mapped types:
   - avro 'double' -> 'float64'
   - most other ones are obvious
 
+Logical types are mapped to richer types when the struct field has that type,
+otherwise the underlying avro type is used as above:
+
+  - 'date', 'timestamp-millis', 'timestamp-micros', 'local-timestamp-millis'
+    and 'local-timestamp-micros' -> time.Time
+  - 'time-millis' and 'time-micros' -> time.Duration
+  - 'decimal' on bytes or fixed -> *big.Rat, values which don't fit the
+    precision and scale of the schema are rejected when writing
+  - 'uuid' on string -> UUID, plain strings are checked to be valid uuids
+    when writing
+  - 'duration' on fixed of size 12 -> Duration
+
 Type unions are a bit more tricky. For a complex type union, the only valid
 mapping is interface{}. However, for a type union with only "null" and one
 other type (very typical) you can map it as a pointer type and keep type safety.
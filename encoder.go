@@ -53,12 +53,52 @@ type Encoder interface {
 	WriteRaw([]byte)
 }
 
+// ErrorEncoder is an Encoder which records the first error it encounters, e.g. from its underlying io.Writer. Nothing
+// is written after an error. The binary and JSON encoders implement it, and the datum writers return its error.
+type ErrorEncoder interface {
+	Encoder
+
+	// Err returns the first error encountered, or nil.
+	Err() error
+}
+
+var _ ErrorEncoder = (*binaryEncoder)(nil)
+var _ ErrorEncoder = (*JSONEncoder)(nil)
+
+// DatumEncoder is an Encoder which delimits datums, like JSONEncoder. The datum writers call StartDatum before writing
+// each datum, so datums written without calling the Encoder, like nulls, are written too.
+type DatumEncoder interface {
+	Encoder
+
+	// StartDatum starts writing a datum.
+	StartDatum()
+}
+
+var _ DatumEncoder = (*JSONEncoder)(nil)
+
+// encoderStartDatum starts a datum of a DatumEncoder.
+func encoderStartDatum(enc Encoder) {
+	if e, ok := enc.(DatumEncoder); ok {
+		e.StartDatum()
+	}
+}
+
+// encoderErr returns the error recorded by an ErrorEncoder.
+func encoderErr(enc Encoder) error {
+	if e, ok := enc.(ErrorEncoder); ok {
+		return e.Err()
+	}
+	return nil
+}
+
 // BinaryEncoder implements Encoder and provides low-level support for serializing Avro values.
 type binaryEncoder struct {
 	buffer io.Writer
+	err    error
 }
 
 // NewBinaryEncoder creates a new BinaryEncoder that will write to a given io.Writer.
+// The returned Encoder is an ErrorEncoder which records the first error of the io.Writer.
 func NewBinaryEncoder(buffer io.Writer) Encoder {
 	return newBinaryEncoder(buffer)
 }
@@ -67,6 +107,17 @@ func newBinaryEncoder(buffer io.Writer) *binaryEncoder {
 	return &binaryEncoder{buffer: buffer}
 }
 
+// Err returns the first error returned by the underlying io.Writer.
+func (be *binaryEncoder) Err() error {
+	return be.err
+}
+
+func (be *binaryEncoder) write(x []byte) {
+	if be.err == nil {
+		_, be.err = be.buffer.Write(x)
+	}
+}
+
 // WriteNull writes a null value. Doesn't actually do anything in this implementation.
 func (be *binaryEncoder) WriteNull(_ interface{}) {
 	//do nothing
@@ -79,52 +130,54 @@ var encBoolFalse = []byte{0x00}
 // WriteBoolean writes a boolean value.
 func (be *binaryEncoder) WriteBoolean(x bool) {
 	if x {
-		_, _ = be.buffer.Write(encBoolTrue)
+		be.write(encBoolTrue)
 	} else {
-		_, _ = be.buffer.Write(encBoolFalse)
+		be.write(encBoolFalse)
 	}
 }
 
 // WriteInt writes an int value.
 func (be *binaryEncoder) WriteInt(x int32) {
-	_, _ = be.buffer.Write(be.encodeVarint32(x))
+	be.write(be.encodeVarint32(x))
 }
 
 // WriteLong writes a long value.
 func (be *binaryEncoder) WriteLong(x int64) {
-	_, _ = be.buffer.Write(be.encodeVarint64(x))
+	be.write(be.encodeVarint64(x))
 }
 
 // WriteFloat writes a float value.
 func (be *binaryEncoder) WriteFloat(x float32) {
 	bytes := make([]byte, 4)
 	binary.LittleEndian.PutUint32(bytes, math.Float32bits(x))
-	_, _ = be.buffer.Write(bytes)
+	be.write(bytes)
 }
 
 // WriteDouble writes a double value.
 func (be *binaryEncoder) WriteDouble(x float64) {
 	bytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(bytes, math.Float64bits(x))
-	_, _ = be.buffer.Write(bytes)
+	be.write(bytes)
 }
 
 // WriteRaw writes raw bytes to this Encoder.
 func (be *binaryEncoder) WriteRaw(x []byte) {
-	_, _ = be.buffer.Write(x)
+	be.write(x)
 }
 
 // WriteBytes writes a bytes value.
 func (be *binaryEncoder) WriteBytes(x []byte) {
 	be.WriteLong(int64(len(x)))
-	_, _ = be.buffer.Write(x)
+	be.write(x)
 }
 
 // WriteString writes a string value.
 func (be *binaryEncoder) WriteString(x string) {
 	be.WriteLong(int64(len(x)))
 	// call writers that happen to provide WriteString to avoid extra byte allocations for a copy of a string when possible.
-	_, _ = io.WriteString(be.buffer, x)
+	if be.err == nil {
+		_, be.err = io.WriteString(be.buffer, x)
+	}
 }
 
 // WriteArrayStart should be called when starting to serialize an array providing it with a number of items in
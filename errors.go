@@ -33,6 +33,12 @@ var ErrInvalidStringLength = errors.New("Invalid string length")
 // Indicates the given file to decode does not correspond to Avro data file format.
 var ErrNotAvroFile = errors.New("Not an Avro data file")
 
+// Indicates the given message doesn't start with the single-object encoding marker.
+var ErrNotSingleObject = errors.New("Not a single-object encoded message")
+
+// Happens when a block of an Avro data file fails its checksum or can't be decompressed.
+var ErrCorruptBlock = errors.New("Corrupt block")
+
 // Happens when trying to read next block without finishing the previous one.
 var ErrBlockNotFinished = errors.New("Block read is unfinished")
 
@@ -0,0 +1,185 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"strconv"
+	"strings"
+)
+
+// ParsingCanonicalForm returns the Parsing Canonical Form of a schema as defined by the spec: all attributes
+// irrelevant to reading data (doc, aliases, defaults, logical types, custom properties...) are stripped, names are
+// fully qualified, named types are only defined at their first occurrence and all JSON is written in a fixed order
+// without whitespace. Two schemas with the same canonical form read and write the same data.
+//
+// Unlike Schema.Fingerprint, which is order independent and specific to this library, fingerprints of the canonical
+// form match those computed by the other Avro implementations.
+// Spec: https://avro.apache.org/docs/current/spec.html#Parsing+Canonical+Form+for+Schemas
+func ParsingCanonicalForm(schema Schema) string {
+	var buf bytes.Buffer
+	writeCanonicalForm(&buf, schema, "", make(map[string]bool))
+	return buf.String()
+}
+
+// RabinFingerprint returns the CRC-64-AVRO fingerprint of the Parsing Canonical Form of a schema. This is the
+// fingerprint used by the single-object encoding.
+func RabinFingerprint(schema Schema) uint64 {
+	return CRC64Avro([]byte(ParsingCanonicalForm(schema)))
+}
+
+// MD5Fingerprint returns the MD5 fingerprint of the Parsing Canonical Form of a schema.
+func MD5Fingerprint(schema Schema) [md5.Size]byte {
+	return md5.Sum([]byte(ParsingCanonicalForm(schema)))
+}
+
+// SHA256Fingerprint returns the SHA-256 fingerprint of the Parsing Canonical Form of a schema.
+func SHA256Fingerprint(schema Schema) [sha256.Size]byte {
+	return sha256.Sum256([]byte(ParsingCanonicalForm(schema)))
+}
+
+// crc64AvroEmpty is the CRC-64-AVRO of an empty input, which is also the polynomial of the checksum.
+const crc64AvroEmpty uint64 = 0xc15d213aa4d7a795
+
+var crc64AvroTable = func() (table [256]uint64) {
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (crc64AvroEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return
+}()
+
+// CRC64Avro computes the 64-bit Rabin fingerprint of the given data as specified for schema fingerprints.
+func CRC64Avro(data []byte) uint64 {
+	fp := crc64AvroEmpty
+	for _, b := range data {
+		fp = (fp >> 8) ^ crc64AvroTable[byte(fp)^b]
+	}
+	return fp
+}
+
+func writeCanonicalForm(buf *bytes.Buffer, schema Schema, namespace string, seen map[string]bool) {
+	switch s := schema.(type) {
+	case *NullSchema, *BooleanSchema, *IntSchema, *LongSchema, *FloatSchema, *DoubleSchema, *BytesSchema,
+		*StringSchema:
+		writeCanonicalString(buf, s.GetName())
+	case *RecordSchema:
+		writeCanonicalRecord(buf, s, namespace, seen)
+	case *preparedRecordSchema:
+		writeCanonicalRecord(buf, &s.RecordSchema, namespace, seen)
+	case *RecursiveSchema:
+		writeCanonicalRecord(buf, s.Actual, namespace, seen)
+	case *refSchema:
+		if seen[s.Type_] {
+			writeCanonicalString(buf, s.Type_)
+		} else {
+			writeCanonicalForm(buf, s.Ref, namespace, seen)
+		}
+	case *EnumSchema:
+		fullname := canonicalName(s.Name, s.Namespace, namespace)
+		if writeCanonicalName(buf, fullname, typeEnum, seen) {
+			buf.WriteString(`,"symbols":[`)
+			for i, symbol := range s.Symbols {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeCanonicalString(buf, symbol)
+			}
+			buf.WriteString("]}")
+		}
+	case *FixedSchema:
+		fullname := canonicalName(s.Name, s.Namespace, namespace)
+		if writeCanonicalName(buf, fullname, typeFixed, seen) {
+			buf.WriteString(`,"size":`)
+			buf.WriteString(strconv.Itoa(s.Size))
+			buf.WriteByte('}')
+		}
+	case *ArraySchema:
+		buf.WriteString(`{"type":"array","items":`)
+		writeCanonicalForm(buf, s.Items, namespace, seen)
+		buf.WriteByte('}')
+	case *MapSchema:
+		buf.WriteString(`{"type":"map","values":`)
+		writeCanonicalForm(buf, s.Values, namespace, seen)
+		buf.WriteByte('}')
+	case *UnionSchema:
+		buf.WriteByte('[')
+		for i, t := range s.Types {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalForm(buf, t, namespace, seen)
+		}
+		buf.WriteByte(']')
+	}
+}
+
+func writeCanonicalRecord(buf *bytes.Buffer, s *RecordSchema, namespace string, seen map[string]bool) {
+	fullname := canonicalName(s.Name, s.Namespace, namespace)
+	if writeCanonicalName(buf, fullname, typeRecord, seen) {
+		buf.WriteString(`,"fields":[`)
+		for i, field := range s.Fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"name":`)
+			writeCanonicalString(buf, field.Name)
+			buf.WriteString(`,"type":`)
+			writeCanonicalForm(buf, field.Type, canonicalNamespace(fullname), seen)
+			buf.WriteByte('}')
+		}
+		buf.WriteString("]}")
+	}
+}
+
+// writeCanonicalName writes a reference to a named type which has been defined before or starts its definition,
+// in which case it returns true and the caller is expected to write the remaining attributes.
+func writeCanonicalName(buf *bytes.Buffer, fullname string, typeName string, seen map[string]bool) bool {
+	if seen[fullname] {
+		writeCanonicalString(buf, fullname)
+		return false
+	}
+	seen[fullname] = true
+	buf.WriteString(`{"name":`)
+	writeCanonicalString(buf, fullname)
+	buf.WriteString(`,"type":`)
+	writeCanonicalString(buf, typeName)
+	return true
+}
+
+// writeCanonicalString writes a JSON string without escaping anything but quotes and backslashes. Avro names and
+// symbols can't contain either, so this never applies in practice.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalName resolves the full name of a named type from its name, namespace attribute and the namespace of the
+// enclosing named type.
+func canonicalName(name, namespace, enclosing string) string {
+	if strings.ContainsRune(name, '.') {
+		return name
+	} else if namespace != "" {
+		return namespace + "." + name
+	} else if enclosing != "" {
+		return enclosing + "." + name
+	}
+	return name
+}
+
+// canonicalNamespace returns the namespace part of a full name.
+func canonicalNamespace(fullname string) string {
+	if i := strings.LastIndexByte(fullname, '.'); i >= 0 {
+		return fullname[:i]
+	}
+	return ""
+}
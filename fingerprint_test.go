@@ -0,0 +1,103 @@
+package avro
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"testing"
+)
+
+// Test vectors from share/test/data/schema-tests.txt of the reference implementation.
+var canonicalFormTests = []struct {
+	schema      string
+	canonical   string
+	fingerprint int64
+}{
+	{`"null"`, `"null"`, 7195948357588979594},
+	{`{"type":"null"}`, `"null"`, 7195948357588979594},
+	{`"boolean"`, `"boolean"`, -6970731678124411036},
+	{`{"type":"boolean"}`, `"boolean"`, -6970731678124411036},
+	{`"int"`, `"int"`, 8247732601305521295},
+	{`{"type":"int"}`, `"int"`, 8247732601305521295},
+	{`"long"`, `"long"`, -3434872931120570953},
+	{`{"type":"long"}`, `"long"`, -3434872931120570953},
+	{`"float"`, `"float"`, 5583340709985441680},
+	{`{"type":"float"}`, `"float"`, 5583340709985441680},
+	{`"double"`, `"double"`, -8181574048448539266},
+	{`{"type":"double"}`, `"double"`, -8181574048448539266},
+	{`"bytes"`, `"bytes"`, 5746618253357095269},
+	{`{"type":"bytes"}`, `"bytes"`, 5746618253357095269},
+	{`"string"`, `"string"`, -8142146995180207161},
+	{`{"type":"string"}`, `"string"`, -8142146995180207161},
+	{`[ ]`, `[]`, -1241056759729112623},
+	{`[ "int" ]`, `["int"]`, -5232228896498058493},
+	{`[ "int" , {"type":"boolean"} ]`, `["int","boolean"]`, 5392556393470105090},
+	{`{"fields":[], "type":"record", "name":"foo"}`,
+		`{"name":"foo","type":"record","fields":[]}`, -4824392279771201922},
+	{`{"fields":[], "type":"record", "name":"foo", "namespace":"x.y"}`,
+		`{"name":"x.y.foo","type":"record","fields":[]}`, 5916914534497305771},
+	{`{"fields":[], "type":"record", "name":"a.b.foo", "namespace":"x.y"}`,
+		`{"name":"a.b.foo","type":"record","fields":[]}`, -4616218487480524110},
+	{`{"fields":[], "type":"record", "name":"foo", "doc":"Useful info"}`,
+		`{"name":"foo","type":"record","fields":[]}`, -4824392279771201922},
+	{`{"fields":[], "type":"record", "name":"foo", "aliases":["foo","bar"]}`,
+		`{"name":"foo","type":"record","fields":[]}`, -4824392279771201922},
+	{`{"fields":[], "type":"record", "name":"foo", "doc":"foo", "aliases":["foo","bar"]}`,
+		`{"name":"foo","type":"record","fields":[]}`, -4824392279771201922},
+	{`{"fields":[{"type":{"type":"boolean"}, "name":"f1"}], "type":"record", "name":"foo"}`,
+		`{"name":"foo","type":"record","fields":[{"name":"f1","type":"boolean"}]}`, 7843277075252814651},
+	{`{"fields":[{"type":"boolean", "aliases":[], "name":"f1", "default":true},
+	   {"order":"descending","name":"f2","doc":"Hello","type":"int"}], "type":"record", "name":"foo"}`,
+		`{"name":"foo","type":"record","fields":[{"name":"f1","type":"boolean"},{"name":"f2","type":"int"}]}`,
+		-4860222112080293046},
+	{`{"type":"enum", "name":"foo", "symbols":["A1"]}`,
+		`{"name":"foo","type":"enum","symbols":["A1"]}`, -6342190197741309591},
+	{`{"namespace":"x.y.z", "type":"enum", "name":"foo", "doc":"foo bar", "symbols":["A1", "A2"]}`,
+		`{"name":"x.y.z.foo","type":"enum","symbols":["A1","A2"]}`, -4448647247586288245},
+	{`{"name":"foo","type":"fixed","size":15}`,
+		`{"name":"foo","type":"fixed","size":15}`, 1756455273707447556},
+	{`{"namespace":"x.y.z", "type":"fixed", "name":"foo", "doc":"foo bar", "size":32}`,
+		`{"name":"x.y.z.foo","type":"fixed","size":32}`, -3064184465700546786},
+	{`{ "items":{"type":"null"}, "type":"array"}`,
+		`{"type":"array","items":"null"}`, -589620603366471059},
+	{`{ "values":"string", "type":"map"}`,
+		`{"type":"map","values":"string"}`, -8732877298790414990},
+	{`{"name":"PigValue","type":"record",
+	   "fields":[{"name":"value", "type":["null", "int", "long", "PigValue"]}]}`,
+		`{"name":"PigValue","type":"record","fields":[{"name":"value","type":["null","int","long","PigValue"]}]}`,
+		-1759257747318642341},
+}
+
+func TestParsingCanonicalForm(t *testing.T) {
+	for _, test := range canonicalFormTests {
+		schema := MustParseSchema(test.schema)
+		assert(t, ParsingCanonicalForm(schema), test.canonical)
+		assert(t, int64(RabinFingerprint(schema)), test.fingerprint)
+		assert(t, MD5Fingerprint(schema), md5.Sum([]byte(test.canonical)))
+		assert(t, SHA256Fingerprint(schema), sha256.Sum256([]byte(test.canonical)))
+	}
+}
+
+func TestParsingCanonicalFormNamedTypes(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Outer", "namespace": "x.y", "doc": "ignored", "fields": [
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "size": 16, "custom": "ignored"}},
+		{"name": "other", "type": "MD5"},
+		{"name": "inner", "type": {"type": "record", "name": "Inner", "fields": [
+			{"name": "kind", "type": {"type": "enum", "name": "Kind", "namespace": "z", "symbols": ["A", "B"]}},
+			{"name": "same", "type": "z.Kind"},
+			{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 4}}
+		]}},
+		{"name": "tags", "type": {"type": "map", "values": {"type": "array", "items": "Inner"}}}
+	]}`)
+	assert(t, ParsingCanonicalForm(schema), `{"name":"x.y.Outer","type":"record","fields":[`+
+		`{"name":"hash","type":{"name":"x.y.MD5","type":"fixed","size":16}},`+
+		`{"name":"other","type":"x.y.MD5"},`+
+		`{"name":"inner","type":{"name":"x.y.Inner","type":"record","fields":[`+
+		`{"name":"kind","type":{"name":"z.Kind","type":"enum","symbols":["A","B"]}},`+
+		`{"name":"same","type":"z.Kind"},`+
+		`{"name":"at","type":"long"},`+
+		`{"name":"amount","type":"bytes"}]}},`+
+		`{"name":"tags","type":{"type":"map","values":{"type":"array","items":"x.y.Inner"}}}]}`)
+	assert(t, ParsingCanonicalForm(Prepare(schema)), ParsingCanonicalForm(schema))
+	assert(t, CRC64Avro(nil), crc64AvroEmpty)
+}
@@ -1,3 +1,9 @@
 module github.com/daemonl/avro
 
-go 1.12
+go 1.22
+
+require (
+	github.com/golang/snappy v1.0.0
+	github.com/klauspost/compress v1.18.0
+	github.com/ulikunitz/xz v0.5.15
+)
@@ -0,0 +1,408 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// JSONDecoder implements Decoder and reads values in the JSON encoding defined by the spec, as written by
+// JSONEncoder or the reference implementations.
+//
+// Each datum is read as a JSON value of the given schema, values can be separated by any whitespace. The datum
+// readers start each one with StartDatum, so datums which aren't read value by value, like nulls, are consumed too.
+// Missing record fields get their default value if they have one.
+// Spec: https://avro.apache.org/docs/current/spec.html#json_encoding
+type JSONDecoder struct {
+	dec    *json.Decoder
+	schema Schema
+	tokens []jsonToken
+}
+
+// jsonToken is a value in the order and form it is read by the datum readers.
+type jsonToken struct {
+	op    jsonOp
+	value interface{}
+}
+
+// NewJSONDecoder creates a new JSONDecoder that reads values of the given schema from r.
+func NewJSONDecoder(schema Schema, r io.Reader) *JSONDecoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &JSONDecoder{dec: dec, schema: schema}
+}
+
+// StartDatum reads the next datum. Values of a previous datum which weren't read, e.g. because reading it failed, are
+// skipped.
+func (d *JSONDecoder) StartDatum() error {
+	d.tokens = nil
+	return d.readDatum()
+}
+
+// ReadNull reads a null value. Doesn't actually do anything as nulls are checked when the datum is read.
+func (d *JSONDecoder) ReadNull() (interface{}, error) {
+	return nil, nil
+}
+
+// ReadBoolean reads a boolean value.
+func (d *JSONDecoder) ReadBoolean() (bool, error) {
+	if v, err := d.next(jsonBoolean); err != nil {
+		return false, err
+	} else {
+		return v.(bool), nil
+	}
+}
+
+// ReadInt reads an int value, or the index of a union branch.
+func (d *JSONDecoder) ReadInt() (int32, error) {
+	if v, err := d.next(jsonInt, jsonIndex); err != nil {
+		return 0, err
+	} else {
+		return int32(v.(int64)), nil
+	}
+}
+
+// ReadLong reads a long value, or the index of a union branch.
+func (d *JSONDecoder) ReadLong() (int64, error) {
+	if v, err := d.next(jsonLong, jsonIndex); err != nil {
+		return 0, err
+	} else {
+		return v.(int64), nil
+	}
+}
+
+// ReadFloat reads a float value.
+func (d *JSONDecoder) ReadFloat() (float32, error) {
+	if v, err := d.next(jsonFloat); err != nil {
+		return 0, err
+	} else {
+		return float32(v.(float64)), nil
+	}
+}
+
+// ReadDouble reads a double value.
+func (d *JSONDecoder) ReadDouble() (float64, error) {
+	if v, err := d.next(jsonDouble); err != nil {
+		return 0, err
+	} else {
+		return v.(float64), nil
+	}
+}
+
+// ReadBytes reads a bytes value.
+func (d *JSONDecoder) ReadBytes() ([]byte, error) {
+	if v, err := d.next(jsonBytes); err != nil {
+		return nil, err
+	} else {
+		return v.([]byte), nil
+	}
+}
+
+// ReadString reads a string value or map key.
+func (d *JSONDecoder) ReadString() (string, error) {
+	if v, err := d.next(jsonString); err != nil {
+		return "", err
+	} else {
+		return v.(string), nil
+	}
+}
+
+// ReadEnum reads the index of an enum symbol.
+func (d *JSONDecoder) ReadEnum() (int32, error) {
+	if v, err := d.next(jsonIndex); err != nil {
+		return 0, err
+	} else {
+		return int32(v.(int64)), nil
+	}
+}
+
+// ReadArrayStart reads the number of items of an array.
+func (d *JSONDecoder) ReadArrayStart() (int64, error) {
+	return d.readCount(jsonArrayStart)
+}
+
+// ArrayNext reads the end of an array. The JSON encoding doesn't have blocks so this always returns 0.
+func (d *JSONDecoder) ArrayNext() (int64, error) {
+	return d.readCount(jsonArrayNext)
+}
+
+// ReadMapStart reads the number of entries of a map.
+func (d *JSONDecoder) ReadMapStart() (int64, error) {
+	return d.readCount(jsonMapStart)
+}
+
+// MapNext reads the end of a map. The JSON encoding doesn't have blocks so this always returns 0.
+func (d *JSONDecoder) MapNext() (int64, error) {
+	return d.readCount(jsonMapNext)
+}
+
+// ReadFixed reads a fixed value into the given buffer.
+func (d *JSONDecoder) ReadFixed(bytes []byte) error {
+	if v, err := d.next(jsonFixed); err != nil {
+		return err
+	} else if len(v.([]byte)) != len(bytes) {
+		return fmt.Errorf("JSONDecoder: expected fixed of size %d, found %d bytes", len(bytes), len(v.([]byte)))
+	} else {
+		copy(bytes, v.([]byte))
+		return nil
+	}
+}
+
+func (d *JSONDecoder) readCount(op jsonOp) (int64, error) {
+	if v, err := d.next(op); err != nil {
+		return 0, err
+	} else {
+		return v.(int64), nil
+	}
+}
+
+// next returns the value of the next token, reading the next datum if all tokens have been consumed.
+func (d *JSONDecoder) next(ops ...jsonOp) (interface{}, error) {
+	for len(d.tokens) == 0 {
+		if err := d.readDatum(); err != nil {
+			return nil, err
+		}
+	}
+	token := d.tokens[0]
+	for _, op := range ops {
+		if token.op == op {
+			d.tokens = d.tokens[1:]
+			return token.value, nil
+		}
+	}
+	return nil, fmt.Errorf("JSONDecoder: expected %v, found %v", ops[0], token.op)
+}
+
+// readDatum reads the next JSON value and converts it into tokens.
+func (d *JSONDecoder) readDatum() error {
+	var value interface{}
+	if err := d.dec.Decode(&value); err != nil {
+		return err
+	}
+	if err := d.tokenize(value, d.schema, ""); err != nil {
+		d.tokens = nil
+		return err
+	}
+	return nil
+}
+
+func (d *JSONDecoder) emit(op jsonOp, value interface{}) {
+	d.tokens = append(d.tokens, jsonToken{op, value})
+}
+
+// tokenize converts a JSON value of the given schema into the tokens read by the datum readers.
+func (d *JSONDecoder) tokenize(value interface{}, schema Schema, namespace string) error {
+	switch s := resolvedSchema(schema).(type) {
+	case *NullSchema:
+		// nulls aren't read by the datum readers
+		if value != nil {
+			return jsonMismatch(value, s)
+		}
+	case *BooleanSchema:
+		if b, ok := value.(bool); ok {
+			d.emit(jsonBoolean, b)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *IntSchema:
+		if n, ok := jsonInteger(value); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+			d.emit(jsonInt, n)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *LongSchema:
+		if n, ok := jsonInteger(value); ok {
+			d.emit(jsonLong, n)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *FloatSchema:
+		if f, ok := jsonFloat64(value); ok {
+			d.emit(jsonFloat, f)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *DoubleSchema:
+		if f, ok := jsonFloat64(value); ok {
+			d.emit(jsonDouble, f)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *BytesSchema:
+		if b, ok := latin1Bytes(value); ok {
+			d.emit(jsonBytes, b)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *StringSchema:
+		if str, ok := value.(string); ok {
+			d.emit(jsonString, str)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *FixedSchema:
+		if b, ok := latin1Bytes(value); ok && len(b) == s.Size {
+			d.emit(jsonFixed, b)
+		} else {
+			return jsonMismatch(value, s)
+		}
+	case *EnumSchema:
+		if symbol, ok := value.(string); ok {
+			if index := s.IndexOf(symbol); index >= 0 {
+				d.emit(jsonIndex, int64(index))
+				return nil
+			}
+		}
+		return jsonMismatch(value, s)
+	case *ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return jsonMismatch(value, s)
+		}
+		d.emit(jsonArrayStart, int64(len(items)))
+		for _, item := range items {
+			if err := d.tokenize(item, s.Items, namespace); err != nil {
+				return err
+			}
+		}
+		if len(items) > 0 {
+			d.emit(jsonArrayNext, int64(0))
+		}
+	case *MapSchema:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return jsonMismatch(value, s)
+		}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		d.emit(jsonMapStart, int64(len(keys)))
+		for _, key := range keys {
+			d.emit(jsonString, key)
+			if err := d.tokenize(entries[key], s.Values, namespace); err != nil {
+				return err
+			}
+		}
+		if len(keys) > 0 {
+			d.emit(jsonMapNext, int64(0))
+		}
+	case *UnionSchema:
+		return d.tokenizeUnion(value, s, namespace)
+	case *RecordSchema:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return jsonMismatch(value, s)
+		}
+		namespace = canonicalNamespace(canonicalName(s.Name, s.Namespace, namespace))
+		for _, field := range s.Fields {
+			if fieldValue, ok := fields[field.Name]; ok {
+				if err := d.tokenize(fieldValue, field.Type, namespace); err != nil {
+					return err
+				}
+			} else if err := d.tokenizeDefault(field, namespace); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("JSONDecoder: unsupported schema %v", schema)
+	}
+	return nil
+}
+
+func (d *JSONDecoder) tokenizeUnion(value interface{}, s *UnionSchema, namespace string) error {
+	if value == nil {
+		for i, t := range s.Types {
+			if resolvedSchema(t).Type() == Null {
+				d.emit(jsonIndex, int64(i))
+				return nil
+			}
+		}
+		return jsonMismatch(value, s)
+	}
+	if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 {
+		for name, branchValue := range wrapped {
+			for i, t := range s.Types {
+				fullname := jsonTypeName(t, namespace)
+				if name == fullname || name == unqualifiedName(fullname) {
+					d.emit(jsonIndex, int64(i))
+					return d.tokenize(branchValue, t, namespace)
+				}
+			}
+		}
+	}
+	return jsonMismatch(value, s)
+}
+
+// tokenizeDefault uses the default value of a missing field. Defaults of unions are values of the first branch.
+func (d *JSONDecoder) tokenizeDefault(field *SchemaField, namespace string) error {
+	if _, err := field.Type.Generic(field.Default); err != nil {
+		return fmt.Errorf("JSONDecoder: field %s is missing and has no default value", field.Name)
+	}
+	if union, ok := resolvedSchema(field.Type).(*UnionSchema); ok && len(union.Types) > 0 {
+		d.emit(jsonIndex, int64(0))
+		return d.tokenize(field.Default, union.Types[0], namespace)
+	}
+	return d.tokenize(field.Default, field.Type, namespace)
+}
+
+func jsonMismatch(value interface{}, schema Schema) error {
+	return fmt.Errorf("JSONDecoder: %v is not a valid %s value", value, schema.GetName())
+}
+
+// jsonInteger converts numbers parsed from a document or a default value of a schema to an integer.
+func jsonInteger(value interface{}) (int64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	case float64:
+		return int64(n), n == math.Trunc(n)
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+func jsonFloat64(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case string:
+		switch n {
+		case "NaN":
+			return math.NaN(), true
+		case "Infinity":
+			return math.Inf(1), true
+		case "-Infinity":
+			return math.Inf(-1), true
+		}
+	}
+	return 0, false
+}
+
+// latin1Bytes maps each code point of a string to a byte of the same value.
+func latin1Bytes(value interface{}) ([]byte, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, false
+		}
+		b = append(b, byte(r))
+	}
+	return b, true
+}
@@ -0,0 +1,469 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// JSONEncoder implements Encoder and writes values in the JSON encoding defined by the spec: unions are wrapped in
+// an object keyed by the name of the branch, enums are written as their symbols and bytes and fixed values as
+// strings whose code points are the byte values (ISO-8859-1).
+//
+// Unlike the binary encoding, the JSON encoding depends on the schema, so the encoder follows the given schema as
+// values are written. Each datum is written as a JSON value on its own line. The binary encoding doesn't delimit
+// datums, so the datum writers start each one with StartDatum, which writes nulls and the structure of records as
+// far as the first value which needs writing. Code writing datums to the encoder itself should call it too.
+// Spec: https://avro.apache.org/docs/current/spec.html#json_encoding
+type JSONEncoder struct {
+	w      io.Writer
+	schema Schema
+	stack  []jsonSymbol
+	err    error
+}
+
+// jsonSymbol is either a value of a schema that is expected next, literal text to write once the preceding values
+// are written or the state of an array or map block being written.
+type jsonSymbol struct {
+	schema    Schema
+	namespace string
+	text      string
+	block     *jsonBlock
+}
+
+type jsonBlock struct {
+	isMap     bool
+	schema    Schema
+	namespace string
+	remaining int64
+	first     bool
+}
+
+// jsonOp identifies the Encoder and Decoder methods a value is written or read with.
+type jsonOp int
+
+const (
+	jsonNull jsonOp = iota
+	jsonBoolean
+	jsonInt
+	jsonLong
+	jsonFloat
+	jsonDouble
+	jsonBytes
+	jsonString
+	jsonFixed
+	jsonIndex
+	jsonArrayStart
+	jsonArrayNext
+	jsonMapStart
+	jsonMapNext
+)
+
+var jsonOpNames = []string{"null", "boolean", "int", "long", "float", "double", "bytes", "string", "fixed", "index",
+	"array start", "array block", "map start", "map block"}
+
+func (op jsonOp) String() string {
+	return jsonOpNames[op]
+}
+
+// NewJSONEncoder creates a new JSONEncoder that writes values of the given schema to w.
+func NewJSONEncoder(schema Schema, w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: w, schema: schema}
+}
+
+// Err returns the first error that occurred while writing, e.g. because the values written don't match the schema.
+// Nothing is written after an error.
+func (e *JSONEncoder) Err() error {
+	return e.err
+}
+
+// StartDatum starts writing a datum, writing it right away if it has no values which need writing, like a null.
+func (e *JSONEncoder) StartDatum() {
+	if e.err != nil {
+		return
+	} else if len(e.stack) > 0 {
+		e.fail("datum started before the end of the previous datum")
+		return
+	}
+	e.push(jsonSymbol{schema: e.schema})
+	e.advance()
+}
+
+// WriteNull does nothing, null values are written as soon as the preceding value is complete.
+func (e *JSONEncoder) WriteNull(_ interface{}) {
+	//do nothing
+}
+
+// WriteBoolean writes a boolean value.
+func (e *JSONEncoder) WriteBoolean(x bool) {
+	e.write(jsonBoolean, x)
+}
+
+// WriteInt writes an int value, or the index of an enum symbol or union branch.
+func (e *JSONEncoder) WriteInt(x int32) {
+	e.write(jsonInt, int64(x))
+}
+
+// WriteLong writes a long value, or the index of an enum symbol or union branch.
+func (e *JSONEncoder) WriteLong(x int64) {
+	e.write(jsonLong, x)
+}
+
+// WriteFloat writes a float value.
+func (e *JSONEncoder) WriteFloat(x float32) {
+	e.write(jsonFloat, float64(x))
+}
+
+// WriteDouble writes a double value.
+func (e *JSONEncoder) WriteDouble(x float64) {
+	e.write(jsonDouble, x)
+}
+
+// WriteBytes writes a bytes value.
+func (e *JSONEncoder) WriteBytes(x []byte) {
+	e.write(jsonBytes, x)
+}
+
+// WriteString writes a string value or map key.
+func (e *JSONEncoder) WriteString(x string) {
+	e.write(jsonString, x)
+}
+
+// WriteRaw writes a fixed value.
+func (e *JSONEncoder) WriteRaw(x []byte) {
+	e.write(jsonFixed, x)
+}
+
+// WriteArrayStart starts writing an array with the given number of items.
+func (e *JSONEncoder) WriteArrayStart(count int64) {
+	e.write(jsonArrayStart, count)
+}
+
+// WriteArrayNext continues an array with the given number of items or ends it if the count is 0.
+func (e *JSONEncoder) WriteArrayNext(count int64) {
+	e.write(jsonArrayNext, count)
+}
+
+// WriteMapStart starts writing a map with the given number of entries.
+func (e *JSONEncoder) WriteMapStart(count int64) {
+	e.write(jsonMapStart, count)
+}
+
+// WriteMapNext continues a map with the given number of entries or ends it if the count is 0.
+func (e *JSONEncoder) WriteMapNext(count int64) {
+	e.write(jsonMapNext, count)
+}
+
+func (e *JSONEncoder) fail(format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf("JSONEncoder: "+format, args...)
+	}
+}
+
+func (e *JSONEncoder) emit(text string) {
+	if e.err == nil {
+		if _, err := io.WriteString(e.w, text); err != nil {
+			e.err = err
+		}
+	}
+}
+
+func (e *JSONEncoder) push(symbols ...jsonSymbol) {
+	e.stack = append(e.stack, symbols...)
+}
+
+func (e *JSONEncoder) pop() jsonSymbol {
+	top := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	return top
+}
+
+func (e *JSONEncoder) write(op jsonOp, value interface{}) {
+	if e.err != nil {
+		return
+	}
+	if len(e.stack) == 0 {
+		// datums written without StartDatum start with their first value
+		e.push(jsonSymbol{schema: e.schema})
+		e.advance()
+	}
+	if len(e.stack) == 0 {
+		e.fail("unexpected %v after the end of the datum", op)
+		return
+	}
+
+	if block := e.stack[len(e.stack)-1].block; block != nil {
+		count, _ := value.(int64)
+		if block.remaining == 0 {
+			// an empty array or map item is written as a block count of 0 as well, so only
+			// a block which has no items left can be continued or ended
+			if (op == jsonArrayNext && !block.isMap) || (op == jsonMapNext && block.isMap) {
+				if count == 0 {
+					e.pop()
+					e.emit(closingBracket(block.isMap))
+					e.advance()
+				} else {
+					block.remaining = count
+				}
+				return
+			}
+			e.fail("unexpected %v, %s has no items left in the block", op, blockName(block.isMap))
+			return
+		}
+		if block.first {
+			block.first = false
+		} else {
+			e.emit(",")
+		}
+		block.remaining--
+		if block.isMap {
+			if op != jsonString {
+				e.fail("expected map key, found %v", op)
+				return
+			}
+			e.emit(quoteJSON(value.(string)) + ":")
+			e.push(jsonSymbol{schema: block.schema, namespace: block.namespace})
+			e.advance()
+			return
+		}
+		e.push(jsonSymbol{schema: block.schema, namespace: block.namespace})
+		e.advance()
+	}
+
+	symbol := e.pop()
+	switch s := resolvedSchema(symbol.schema).(type) {
+	case *UnionSchema:
+		index, ok := value.(int64)
+		if !ok || (op != jsonInt && op != jsonLong) {
+			e.fail("expected union index, found %v", op)
+			return
+		} else if index < 0 || index >= int64(len(s.Types)) {
+			e.fail("invalid union index %d", index)
+			return
+		}
+		branch := s.Types[index]
+		if resolvedSchema(branch).Type() == Null {
+			e.push(jsonSymbol{schema: branch})
+		} else {
+			e.emit("{" + quoteJSON(jsonTypeName(branch, symbol.namespace)) + ":")
+			e.push(jsonSymbol{text: "}"}, jsonSymbol{schema: branch, namespace: symbol.namespace})
+		}
+	case *EnumSchema:
+		index, ok := value.(int64)
+		if !ok || (op != jsonInt && op != jsonLong) {
+			e.fail("expected enum index for %s, found %v", s.GetName(), op)
+			return
+		} else if index < 0 || index >= int64(len(s.Symbols)) {
+			e.fail("invalid index %d for enum %s", index, s.GetName())
+			return
+		}
+		e.emit(quoteJSON(s.Symbols[index]))
+	case *ArraySchema, *MapSchema:
+		isMap := s.Type() == Map
+		count, _ := value.(int64)
+		switch {
+		case isMap && op == jsonMapStart, !isMap && op == jsonArrayStart:
+			e.emit(openingBracket(isMap))
+			block := &jsonBlock{isMap: isMap, namespace: symbol.namespace, remaining: count, first: true}
+			if isMap {
+				block.schema = s.(*MapSchema).Values
+			} else {
+				block.schema = s.(*ArraySchema).Items
+			}
+			e.push(jsonSymbol{block: block})
+			if count == 0 {
+				e.fail("%s must start with a non-empty block", blockName(isMap))
+			}
+		case isMap && op == jsonMapNext && count == 0, !isMap && op == jsonArrayNext && count == 0:
+			// empty arrays and maps only write the end of the array or map
+			e.emit(openingBracket(isMap) + closingBracket(isMap))
+		default:
+			e.fail("expected %s, found %v", s.GetName(), op)
+			return
+		}
+	case *FixedSchema:
+		if op != jsonFixed {
+			e.fail("expected fixed %s, found %v", s.GetName(), op)
+			return
+		} else if len(value.([]byte)) != s.Size {
+			e.fail("expected fixed %s of size %d, found %d bytes", s.GetName(), s.Size, len(value.([]byte)))
+			return
+		}
+		e.emit(quoteJSON(latin1String(value.([]byte))))
+	default:
+		if op != primitiveJSONOp(s) {
+			e.fail("expected %s, found %v", s.GetName(), op)
+			return
+		}
+		switch op {
+		case jsonBoolean:
+			e.emit(strconv.FormatBool(value.(bool)))
+		case jsonInt, jsonLong:
+			e.emit(strconv.FormatInt(value.(int64), 10))
+		case jsonFloat:
+			e.emit(jsonFloatString(value.(float64), 32))
+		case jsonDouble:
+			e.emit(jsonFloatString(value.(float64), 64))
+		case jsonBytes:
+			e.emit(quoteJSON(latin1String(value.([]byte))))
+		case jsonString:
+			e.emit(quoteJSON(value.(string)))
+		}
+	}
+	e.advance()
+}
+
+// advance writes pending text, nulls and the structure of records, up to the next symbol which needs a value to be
+// written. Once the datum is complete, it is terminated by a new line.
+func (e *JSONEncoder) advance() {
+	for len(e.stack) > 0 {
+		top := e.stack[len(e.stack)-1]
+		if block := top.block; block != nil {
+			if block.remaining == 0 || block.isMap || !jsonWritesNothing(block.schema) {
+				return
+			}
+			// items which don't write anything are written right away
+			if block.first {
+				block.first = false
+			} else {
+				e.emit(",")
+			}
+			block.remaining--
+			e.push(jsonSymbol{schema: block.schema, namespace: block.namespace})
+			continue
+		} else if top.schema == nil {
+			e.pop()
+			e.emit(top.text)
+			continue
+		}
+		switch s := resolvedSchema(top.schema).(type) {
+		case *NullSchema:
+			e.pop()
+			e.emit("null")
+		case *RecordSchema:
+			e.pop()
+			namespace := canonicalNamespace(canonicalName(s.Name, s.Namespace, top.namespace))
+			e.push(jsonSymbol{text: "}"})
+			for i := len(s.Fields) - 1; i >= 0; i-- {
+				prefix := ","
+				if i == 0 {
+					prefix = "{"
+				}
+				e.push(jsonSymbol{schema: s.Fields[i].Type, namespace: namespace},
+					jsonSymbol{text: prefix + quoteJSON(s.Fields[i].Name) + ":"})
+			}
+			if len(s.Fields) == 0 {
+				e.push(jsonSymbol{text: "{"})
+			}
+		default:
+			return
+		}
+	}
+	e.emit("\n")
+}
+
+// jsonWritesNothing checks whether values of a schema are written without calling the encoder, which is the case
+// for nulls and records of such values.
+func jsonWritesNothing(schema Schema) bool {
+	switch s := resolvedSchema(schema).(type) {
+	case *NullSchema:
+		return true
+	case *RecordSchema:
+		for _, f := range s.Fields {
+			if !jsonWritesNothing(f.Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func primitiveJSONOp(schema Schema) jsonOp {
+	switch schema.Type() {
+	case Boolean:
+		return jsonBoolean
+	case Int:
+		return jsonInt
+	case Long:
+		return jsonLong
+	case Float:
+		return jsonFloat
+	case Double:
+		return jsonDouble
+	case Bytes:
+		return jsonBytes
+	case String:
+		return jsonString
+	}
+	return jsonNull
+}
+
+// jsonTypeName returns the name a union branch is written with: the full name of named types and the type name
+// of any other type.
+func jsonTypeName(schema Schema, namespace string) string {
+	switch s := resolvedSchema(schema).(type) {
+	case *RecordSchema:
+		return canonicalName(s.Name, s.Namespace, namespace)
+	case *EnumSchema:
+		return canonicalName(s.Name, s.Namespace, namespace)
+	case *FixedSchema:
+		return canonicalName(s.Name, s.Namespace, namespace)
+	default:
+		return s.GetName()
+	}
+}
+
+func quoteJSON(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// jsonFloatString writes non-finite values as the strings used by the reference implementation.
+func jsonFloatString(x float64, bitSize int) string {
+	switch {
+	case math.IsNaN(x):
+		return `"NaN"`
+	case math.IsInf(x, 1):
+		return `"Infinity"`
+	case math.IsInf(x, -1):
+		return `"-Infinity"`
+	}
+	return strconv.FormatFloat(x, 'g', -1, bitSize)
+}
+
+// latin1String maps each byte to the code point of the same value.
+func latin1String(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func openingBracket(isMap bool) string {
+	if isMap {
+		return "{"
+	}
+	return "["
+}
+
+func closingBracket(isMap bool) string {
+	if isMap {
+		return "}"
+	}
+	return "]"
+}
+
+func blockName(isMap bool) string {
+	if isMap {
+		return "map"
+	}
+	return "array"
+}
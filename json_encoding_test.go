@@ -0,0 +1,233 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+const jsonEncodingSchemaRaw = `{"type": "record", "name": "Event", "namespace": "test", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "ok", "type": "boolean"},
+	{"name": "score", "type": "float"},
+	{"name": "ratio", "type": "double"},
+	{"name": "payload", "type": "bytes"},
+	{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 2}},
+	{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+	{"name": "tags", "type": {"type": "array", "items": "string"}},
+	{"name": "empty", "type": {"type": "array", "items": "int"}},
+	{"name": "counts", "type": {"type": "map", "values": "int"}},
+	{"name": "nothing", "type": "null"},
+	{"name": "parent", "type": ["null", {"type": "record", "name": "Parent", "fields": [
+		{"name": "name", "type": "string"}
+	]}]},
+	{"name": "value", "type": ["null", "string", "int", "Kind"]},
+	{"name": "missing", "type": ["null", "int"]}
+]}`
+
+type jsonEvent struct {
+	Id      int64
+	Ok      bool
+	Score   float32
+	Ratio   float64
+	Payload []byte
+	Hash    []byte
+	Kind    EnumValue
+	Tags    []string
+	Empty   []int32
+	Counts  map[string]int32
+	Nothing interface{}
+	Parent  *struct{ Name string }
+	Value   interface{}
+	Missing *int32
+}
+
+const jsonEncodedEvent = `{"id":1,"ok":true,"score":1.5,"ratio":-0.25,"payload":"\u0000ÿA","hash":"\u0001\u0002",` +
+	`"kind":"B","tags":["x","<y>"],"empty":[],"counts":{"a":1},"nothing":null,"parent":{"test.Parent":{"name":"p"}},` +
+	`"value":{"int":7},"missing":null}` + "\n"
+
+func TestJSONEncoder(t *testing.T) {
+	schema := MustParseSchema(jsonEncodingSchemaRaw)
+	kind, _ := schema.(*RecordSchema).Fields[6].Type.(*EnumSchema).Value("B")
+	in := &jsonEvent{
+		Id:      1,
+		Ok:      true,
+		Score:   1.5,
+		Ratio:   -0.25,
+		Payload: []byte{0, 255, 'A'},
+		Hash:    []byte{1, 2},
+		Kind:    kind,
+		Tags:    []string{"x", "<y>"},
+		Empty:   []int32{},
+		Counts:  map[string]int32{"a": 1},
+		Parent:  &struct{ Name string }{"p"},
+		Value:   int32(7),
+	}
+
+	var buf bytes.Buffer
+	enc := NewJSONEncoder(schema, &buf)
+	assert(t, NewDatumWriter(schema).Write(in, enc), nil)
+	assert(t, enc.Err(), nil)
+	assert(t, buf.String(), jsonEncodedEvent)
+
+	// each datum is written on its own line
+	in.Parent, in.Value = nil, "s"
+	assert(t, NewDatumWriter(schema).Write(in, enc), nil)
+	assert(t, enc.Err(), nil)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert(t, len(lines), 2)
+	assert(t, strings.Contains(lines[1], `"parent":null,"value":{"string":"s"}`), true)
+}
+
+func TestJSONEncoderMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewJSONEncoder(MustParseSchema(`{"type": "array", "items": "int"}`), &buf)
+	enc.WriteArrayStart(1)
+	enc.WriteString("not an int")
+	assert(t, enc.Err() != nil, true)
+	enc.WriteArrayNext(0)
+	assert(t, buf.String(), "[")
+}
+
+func TestJSONDecoder(t *testing.T) {
+	schema := MustParseSchema(jsonEncodingSchemaRaw)
+
+	// whitespace, field order and unqualified union branch names don't matter, missing fields get their defaults
+	input := `{"value": {"Kind": "A"}, "id": 1, "ok": true, "score": 1.5, "ratio": -0.25,
+		"payload": "\u0000ÿA", "hash": "\u0001\u0002", "kind": "B", "tags": ["x", "<y>"], "empty": [],
+		"counts": {"a": 1, "b": 2}, "nothing": null, "parent": {"Parent": {"name": "p"}}}
+		` + jsonEncodedEvent
+
+	dec := NewJSONDecoder(schema, strings.NewReader(input))
+	out := &jsonEvent{}
+	assert(t, NewDatumReader(schema).Read(out, dec), nil)
+	assert(t, out.Id, int64(1))
+	assert(t, out.Ok, true)
+	assert(t, out.Score, float32(1.5))
+	assert(t, out.Ratio, -0.25)
+	assert(t, out.Payload, []byte{0, 255, 'A'})
+	assert(t, out.Hash, []byte{1, 2})
+	assert(t, out.Kind.String(), "B")
+	assert(t, out.Tags, []string{"x", "<y>"})
+	assert(t, len(out.Empty), 0)
+	assert(t, out.Counts, map[string]int32{"a": 1, "b": 2})
+	assert(t, out.Parent.Name, "p")
+	assert(t, out.Value.(EnumValue).String(), "A")
+	assert(t, out.Missing, (*int32)(nil))
+
+	record := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(record, dec), nil)
+	assert(t, record.Get("value"), int32(7))
+	assert(t, record.Get("payload"), []byte{0, 255, 'A'})
+
+	_, err := dec.ReadLong()
+	assert(t, err != nil, true)
+
+	for _, invalid := range []string{`{"id": "1"}`, `{"id": 1.5}`, `{"id": 1, "value": {"boolean": true}}`} {
+		dec := NewJSONDecoder(schema, strings.NewReader(invalid))
+		assert(t, NewDatumReader(schema).Read(&jsonEvent{}, dec) != nil, true)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "label", "type": "string"},
+		{"name": "weight", "type": "double"},
+		{"name": "children", "type": {"type": "array", "items": "Node"}},
+		{"name": "attributes", "type": {"type": "map", "values": ["null", "long", {"type": "map", "values": "string"}]}}
+	]}`)
+
+	child := NewGenericRecord(schema)
+	child.Set("label", "child \"quoted\"")
+	child.Set("weight", math.Inf(1))
+	child.Set("children", []interface{}{})
+	child.Set("attributes", map[string]interface{}{})
+	root := NewGenericRecord(schema)
+	root.Set("label", "root")
+	root.Set("weight", 0.1)
+	root.Set("children", []interface{}{child})
+	root.Set("attributes", map[string]interface{}{"n": nil, "l": int64(5)})
+
+	var buf bytes.Buffer
+	enc := NewJSONEncoder(schema, &buf)
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(root, enc), nil)
+	assert(t, enc.Err(), nil)
+
+	out := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(out, NewJSONDecoder(schema, &buf)), nil)
+	assert(t, out.Get("label"), "root")
+	assert(t, out.Get("weight"), 0.1)
+	assert(t, out.Get("attributes"), map[string]interface{}{"n": nil, "l": int64(5)})
+	outChild := out.Get("children").([]interface{})[0].(*GenericRecord)
+	assert(t, outChild.Get("label"), "child \"quoted\"")
+	assert(t, outChild.Get("weight"), math.Inf(1))
+}
+
+func TestJSONRoundTripNothingToWrite(t *testing.T) {
+	nullSchema := MustParseSchema(`"null"`)
+	var buf bytes.Buffer
+	enc := NewJSONEncoder(nullSchema, &buf)
+	for i := 0; i < 2; i++ {
+		assert(t, NewGenericDatumWriter().SetSchema(nullSchema).Write(nil, enc), nil)
+	}
+	assert(t, buf.String(), "null\nnull\n")
+	dec := NewJSONDecoder(nullSchema, &buf)
+	for i := 0; i < 2; i++ {
+		var out interface{}
+		assert(t, NewGenericDatumReader().SetSchema(nullSchema).Read(&out, dec), nil)
+		assert(t, out, nil)
+	}
+	var out interface{}
+	assert(t, NewGenericDatumReader().SetSchema(nullSchema).Read(&out, dec), io.EOF)
+
+	// records of nulls are written in full and stay in sync with the datums which follow
+	schema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": "null"},
+		{"name": "b", "type": {"type": "record", "name": "Empty", "fields": []}}
+	]}`)
+	in := NewGenericRecord(schema)
+	in.Set("b", NewGenericRecord(schema.(*RecordSchema).Fields[1].Type))
+	buf.Reset()
+	enc = NewJSONEncoder(schema, &buf)
+	for i := 0; i < 2; i++ {
+		assert(t, NewGenericDatumWriter().SetSchema(schema).Write(in, enc), nil)
+	}
+	assert(t, buf.String(), `{"a":null,"b":{}}`+"\n"+`{"a":null,"b":{}}`+"\n")
+	dec = NewJSONDecoder(schema, strings.NewReader(buf.String()+`{"a": null, "b": {}}`))
+	for i := 0; i < 3; i++ {
+		rec := NewGenericRecord(schema)
+		assert(t, NewGenericDatumReader().SetSchema(schema).Read(rec, dec), nil)
+		assert(t, rec.Get("a"), nil)
+	}
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(NewGenericRecord(schema), dec), io.EOF)
+
+	// StartDatum is only valid once the previous datum is complete
+	enc = NewJSONEncoder(MustParseSchema(`"long"`), &buf)
+	enc.StartDatum()
+	enc.StartDatum()
+	assert(t, enc.Err().Error(), "JSONEncoder: datum started before the end of the previous datum")
+}
+
+func TestJSONDecoderProjection(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": "int"},
+		{"name": "b", "type": ["null", "string"]}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": "long"},
+		{"name": "c", "type": "string", "default": "c"}
+	]}`)
+	projector, err := NewDatumProjector(readerSchema, writerSchema)
+	assert(t, err, nil)
+
+	var out struct {
+		A int64
+		C string
+	}
+	dec := NewJSONDecoder(writerSchema, strings.NewReader(`{"a": 5, "b": {"string": "ignored"}}`))
+	assert(t, projector.Read(&out, dec), nil)
+	assert(t, out.A, int64(5))
+	assert(t, out.C, "c")
+}
@@ -0,0 +1,531 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Logical types annotate a primitive or fixed schema with a `logicalType` attribute so that values can be mapped to
+// richer native Go types. Unknown logical types, or logical types on a schema type they don't apply to, are preserved
+// when the schema is serialized but otherwise ignored, as required by the spec.
+// Spec: https://avro.apache.org/docs/current/spec.html#Logical+Types
+const (
+	// LogicalTypeDate annotates an int with the number of days since the unix epoch. Maps to time.Time.
+	LogicalTypeDate = "date"
+
+	// LogicalTypeTimeMillis annotates an int with the number of milliseconds after midnight. Maps to time.Duration.
+	LogicalTypeTimeMillis = "time-millis"
+
+	// LogicalTypeTimeMicros annotates a long with the number of microseconds after midnight. Maps to time.Duration.
+	LogicalTypeTimeMicros = "time-micros"
+
+	// LogicalTypeTimestampMillis annotates a long with the number of milliseconds since the unix epoch.
+	// Maps to time.Time.
+	LogicalTypeTimestampMillis = "timestamp-millis"
+
+	// LogicalTypeTimestampMicros annotates a long with the number of microseconds since the unix epoch.
+	// Maps to time.Time.
+	LogicalTypeTimestampMicros = "timestamp-micros"
+
+	// LogicalTypeLocalTimestampMillis annotates a long with the number of milliseconds from 1970-01-01T00:00:00 in
+	// an unspecified local time zone. Maps to time.Time, the wall clock of which is read and written as if in UTC.
+	LogicalTypeLocalTimestampMillis = "local-timestamp-millis"
+
+	// LogicalTypeLocalTimestampMicros annotates a long with the number of microseconds from 1970-01-01T00:00:00 in
+	// an unspecified local time zone. Maps to time.Time, the wall clock of which is read and written as if in UTC.
+	LogicalTypeLocalTimestampMicros = "local-timestamp-micros"
+
+	// LogicalTypeDecimal annotates bytes or a fixed with the two's-complement big-endian unscaled value of an
+	// arbitrary-precision decimal number. The `precision` and `scale` attributes give the maximum number of digits
+	// and the number of digits after the decimal point. Maps to *big.Rat.
+	LogicalTypeDecimal = "decimal"
+
+	// LogicalTypeUUID annotates a string with a universally unique identifier as specified by RFC 4122.
+	// Maps to UUID.
+	LogicalTypeUUID = "uuid"
+
+	// LogicalTypeDuration annotates a fixed of size 12 with three little-endian unsigned ints holding a number of
+	// months, days and milliseconds. Maps to Duration.
+	LogicalTypeDuration = "duration"
+)
+
+// UUID is the native value of the uuid logical type. It is written in its canonical textual representation.
+type UUID [16]byte
+
+// ParseUUID parses the canonical textual representation of a UUID, e.g. "123e4567-e89b-12d3-a456-426655440000".
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid uuid: %q", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, fmt.Errorf("invalid uuid: %q", s)
+	}
+	return u, nil
+}
+
+// String returns the canonical textual representation of the UUID.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// Duration is the native value of the duration logical type. Unlike time.Duration, the length of months and
+// days is left to the application as the spec doesn't relate them to each other.
+type Duration struct {
+	Months       uint32
+	Days         uint32
+	Milliseconds uint32
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+	decimalType      = reflect.TypeOf(&big.Rat{})
+	uuidType         = reflect.TypeOf(UUID{})
+	avroDurationType = reflect.TypeOf(Duration{})
+)
+
+// logicalTypeOf returns the logical type of a schema if it is one this library maps to a native Go value
+// and it applies to the underlying type of the schema. Returns an empty string otherwise.
+func logicalTypeOf(schema Schema) string {
+	switch s := schema.(type) {
+	case *IntSchema:
+		switch s.LogicalType {
+		case LogicalTypeDate, LogicalTypeTimeMillis:
+			return s.LogicalType
+		}
+	case *LongSchema:
+		switch s.LogicalType {
+		case LogicalTypeTimeMicros, LogicalTypeTimestampMillis, LogicalTypeTimestampMicros,
+			LogicalTypeLocalTimestampMillis, LogicalTypeLocalTimestampMicros:
+			return s.LogicalType
+		}
+	case *StringSchema:
+		if s.LogicalType == LogicalTypeUUID {
+			return s.LogicalType
+		}
+	case *BytesSchema:
+		if s.LogicalType == LogicalTypeDecimal && validDecimal(s.Precision, s.Scale, -1) {
+			return s.LogicalType
+		}
+	case *FixedSchema:
+		if s.LogicalType == LogicalTypeDecimal && validDecimal(s.Precision, s.Scale, s.Size) {
+			return s.LogicalType
+		} else if s.LogicalType == LogicalTypeDuration && s.Size == 12 {
+			return s.LogicalType
+		}
+	case *refSchema:
+		return logicalTypeOf(s.Ref)
+	}
+	return ""
+}
+
+// isLogical checks whether values of the given schema are mapped to a native Go type.
+func isLogical(schema Schema) bool {
+	return logicalTypeOf(schema) != ""
+}
+
+// physicalSchema returns the given schema stripped of its logical type, e.g. the type values are encoded as.
+func physicalSchema(schema Schema) Schema {
+	switch s := schema.(type) {
+	case *IntSchema:
+		return new(IntSchema)
+	case *LongSchema:
+		return new(LongSchema)
+	case *StringSchema:
+		return new(StringSchema)
+	case *BytesSchema:
+		return new(BytesSchema)
+	case *FixedSchema:
+		return &FixedSchema{Namespace: s.Namespace, Name: s.Name, Size: s.Size, Properties: s.Properties}
+	case *refSchema:
+		return physicalSchema(s.Ref)
+	}
+	return schema
+}
+
+// logicalNativeType returns the Go type values of the given logical schema are mapped to.
+func logicalNativeType(schema Schema) reflect.Type {
+	switch logicalTypeOf(schema) {
+	case LogicalTypeDate, LogicalTypeTimestampMillis, LogicalTypeTimestampMicros,
+		LogicalTypeLocalTimestampMillis, LogicalTypeLocalTimestampMicros:
+		return timeType
+	case LogicalTypeTimeMillis, LogicalTypeTimeMicros:
+		return durationType
+	case LogicalTypeDecimal:
+		return decimalType
+	case LogicalTypeUUID:
+		return uuidType
+	case LogicalTypeDuration:
+		return avroDurationType
+	}
+	return nil
+}
+
+// acceptsLogical checks whether the native value of a logical schema can be stored in a value of type t.
+// Types which don't accept the native value (e.g. an int64 field for a timestamp) get the underlying value instead.
+func acceptsLogical(schema Schema, t reflect.Type) bool {
+	native := logicalNativeType(schema)
+	if native == nil {
+		return false
+	}
+	return indirectType(t) == indirectType(native) || (t.Kind() == reflect.Interface && native.Implements(t))
+}
+
+// validateLogical checks whether the given value is a native value of the logical schema.
+func validateLogical(schema Schema, v reflect.Value) bool {
+	native := logicalNativeType(schema)
+	v = dereference(v)
+	return native != nil && v.IsValid() && v.Type() == indirectType(native)
+}
+
+// logicalValue returns the native value of a logical schema as a value assignable to type t, adding or removing
+// a level of indirection as needed.
+func logicalValue(native interface{}, t reflect.Type) reflect.Value {
+	v := reflect.ValueOf(native)
+	if t.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
+		ref := reflect.New(v.Type())
+		ref.Elem().Set(v)
+		return ref
+	} else if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && v.Kind() == reflect.Ptr {
+		return v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// decodeLogical converts a value of the underlying type of a logical schema into its native Go value.
+func decodeLogical(schema Schema, value interface{}) (interface{}, error) {
+	switch logicalTypeOf(schema) {
+	case LogicalTypeDate:
+		return time.Unix(int64(value.(int32))*secondsPerDay, 0).UTC(), nil
+	case LogicalTypeTimeMillis:
+		return time.Duration(value.(int32)) * time.Millisecond, nil
+	case LogicalTypeTimeMicros:
+		return time.Duration(value.(int64)) * time.Microsecond, nil
+	case LogicalTypeTimestampMillis, LogicalTypeLocalTimestampMillis:
+		millis := value.(int64)
+		return time.Unix(millis/1e3, (millis%1e3)*1e6).UTC(), nil
+	case LogicalTypeTimestampMicros, LogicalTypeLocalTimestampMicros:
+		micros := value.(int64)
+		return time.Unix(micros/1e6, (micros%1e6)*1e3).UTC(), nil
+	case LogicalTypeDecimal:
+		return decodeDecimal(value.([]byte), decimalScale(schema)), nil
+	case LogicalTypeUUID:
+		return ParseUUID(value.(string))
+	case LogicalTypeDuration:
+		b := value.([]byte)
+		return Duration{
+			Months:       binary.LittleEndian.Uint32(b[0:4]),
+			Days:         binary.LittleEndian.Uint32(b[4:8]),
+			Milliseconds: binary.LittleEndian.Uint32(b[8:12]),
+		}, nil
+	}
+	return value, nil
+}
+
+// encodeLogical converts a native Go value of a logical schema into a value of its underlying type.
+// The returned bool is false if the datum is not a native value of the logical type, in which case
+// it should be written as is.
+func encodeLogical(schema Schema, datum interface{}) (interface{}, bool, error) {
+	switch logicalType := logicalTypeOf(schema); logicalType {
+	case LogicalTypeDate:
+		if t, ok := asTime(datum); ok {
+			year, month, day := t.Date()
+			days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay
+			if days < math.MinInt32 || days > math.MaxInt32 {
+				return nil, true, fmt.Errorf("date out of range: %v", t)
+			}
+			return int32(days), true, nil
+		}
+	case LogicalTypeTimeMillis:
+		if d, ok := asDuration(datum); ok {
+			millis := int64(d / time.Millisecond)
+			if millis < math.MinInt32 || millis > math.MaxInt32 {
+				return nil, true, fmt.Errorf("time-millis out of range: %v", d)
+			}
+			return int32(millis), true, nil
+		}
+	case LogicalTypeTimeMicros:
+		if d, ok := asDuration(datum); ok {
+			return int64(d / time.Microsecond), true, nil
+		}
+	case LogicalTypeTimestampMillis, LogicalTypeLocalTimestampMillis:
+		if t, ok := asTime(datum); ok {
+			if logicalType == LogicalTypeLocalTimestampMillis {
+				t = wallClockAsUTC(t)
+			}
+			return t.Unix()*1e3 + int64(t.Nanosecond())/1e6, true, nil
+		}
+	case LogicalTypeTimestampMicros, LogicalTypeLocalTimestampMicros:
+		if t, ok := asTime(datum); ok {
+			if logicalType == LogicalTypeLocalTimestampMicros {
+				t = wallClockAsUTC(t)
+			}
+			return t.Unix()*1e6 + int64(t.Nanosecond())/1e3, true, nil
+		}
+	case LogicalTypeDecimal:
+		if r, ok := asDecimal(datum); ok {
+			value, err := encodeDecimal(r, schema)
+			return value, true, err
+		}
+	case LogicalTypeUUID:
+		if u, ok := asUUID(datum); ok {
+			return u.String(), true, nil
+		} else if s, ok := datum.(string); ok {
+			// plain strings are written as they are, but only if they are valid uuids
+			if _, err := ParseUUID(s); err != nil {
+				return nil, true, err
+			}
+			return s, true, nil
+		}
+	case LogicalTypeDuration:
+		if d, ok := asAvroDuration(datum); ok {
+			b := make([]byte, 12)
+			binary.LittleEndian.PutUint32(b[0:4], d.Months)
+			binary.LittleEndian.PutUint32(b[4:8], d.Days)
+			binary.LittleEndian.PutUint32(b[8:12], d.Milliseconds)
+			return b, true, nil
+		}
+	}
+	return datum, false, nil
+}
+
+// genericLogical implements Schema.Generic for logical schemas: native values are accepted as they are and
+// anything else is converted by the underlying schema and then mapped to the native value.
+func genericLogical(schema Schema, datum interface{}) (interface{}, error) {
+	switch logicalNativeType(schema) {
+	case timeType:
+		if t, ok := asTime(datum); ok {
+			return t, nil
+		} else if s, ok := datum.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t, nil
+			}
+		}
+	case durationType:
+		if d, ok := asDuration(datum); ok {
+			return d, nil
+		}
+	case decimalType:
+		if r, ok := asDecimal(datum); ok {
+			return r, nil
+		} else if f, ok := datum.(float64); ok {
+			// the shortest decimal form of f, as SetFloat64 keeps the binary error of values like 0.1
+			if r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64)); ok {
+				return r, nil
+			}
+		}
+	case uuidType:
+		if u, ok := asUUID(datum); ok {
+			return u, nil
+		}
+	case avroDurationType:
+		if d, ok := asAvroDuration(datum); ok {
+			return d, nil
+		}
+	}
+	if value, err := physicalSchema(schema).Generic(datum); err != nil {
+		return nil, err
+	} else {
+		return decodeLogical(schema, value)
+	}
+}
+
+func asTime(datum interface{}) (time.Time, bool) {
+	switch t := datum.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t != nil {
+			return *t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func asDuration(datum interface{}) (time.Duration, bool) {
+	switch d := datum.(type) {
+	case time.Duration:
+		return d, true
+	case *time.Duration:
+		if d != nil {
+			return *d, true
+		}
+	}
+	return 0, false
+}
+
+func asDecimal(datum interface{}) (*big.Rat, bool) {
+	switch r := datum.(type) {
+	case *big.Rat:
+		if r != nil {
+			return r, true
+		}
+	case big.Rat:
+		return &r, true
+	}
+	return nil, false
+}
+
+func asUUID(datum interface{}) (UUID, bool) {
+	switch u := datum.(type) {
+	case UUID:
+		return u, true
+	case *UUID:
+		if u != nil {
+			return *u, true
+		}
+	}
+	return UUID{}, false
+}
+
+func asAvroDuration(datum interface{}) (Duration, bool) {
+	switch d := datum.(type) {
+	case Duration:
+		return d, true
+	case *Duration:
+		if d != nil {
+			return *d, true
+		}
+	}
+	return Duration{}, false
+}
+
+// wallClockAsUTC returns the instant with the same wall clock reading as t in UTC.
+func wallClockAsUTC(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), time.UTC)
+}
+
+// validDecimal checks the precision and scale of a decimal, which must fit into size bytes unless size is negative.
+// Decimals with invalid attributes are read and written as their underlying type.
+func validDecimal(precision, scale, size int) bool {
+	if precision <= 0 || scale < 0 || scale > precision {
+		return false
+	}
+	return size < 0 || precision <= maxDecimalPrecision(size)
+}
+
+// maxDecimalPrecision returns the number of base 10 digits that fit into a two's-complement number of size bytes.
+func maxDecimalPrecision(size int) int {
+	if size <= 0 {
+		return 0
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*size-1))
+	return len(max.Sub(max, big.NewInt(1)).String()) - 1
+}
+
+func decimalScale(schema Schema) int {
+	switch s := schema.(type) {
+	case *BytesSchema:
+		return s.Scale
+	case *FixedSchema:
+		return s.Scale
+	case *refSchema:
+		return decimalScale(s.Ref)
+	}
+	return 0
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// decodeDecimal reads a two's-complement big-endian unscaled value.
+func decodeDecimal(value []byte, scale int) *big.Rat {
+	unscaled := new(big.Int).SetBytes(value)
+	if len(value) > 0 && value[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(value))))
+	}
+	return new(big.Rat).SetFrac(unscaled, pow10(scale))
+}
+
+// decimalOf returns the precision and scale of a decimal schema.
+func decimalOf(schema Schema) (precision, scale int) {
+	switch s := schema.(type) {
+	case *BytesSchema:
+		return s.Precision, s.Scale
+	case *FixedSchema:
+		return s.Precision, s.Scale
+	case *refSchema:
+		return decimalOf(s.Ref)
+	}
+	return 0, 0
+}
+
+// encodeDecimal writes the unscaled value of r as the shortest two's-complement big-endian byte slice, or sign
+// extended to the size of a fixed. Values that don't fit the precision and scale of the schema are rejected rather
+// than rounded.
+func encodeDecimal(r *big.Rat, schema Schema) ([]byte, error) {
+	var precision, scale, size int
+	switch s := schema.(type) {
+	case *BytesSchema:
+		precision, scale, size = s.Precision, s.Scale, -1
+	case *FixedSchema:
+		precision, scale, size = s.Precision, s.Scale, s.Size
+	case *refSchema:
+		return encodeDecimal(r, s.Ref)
+	}
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("decimal %s has more than %d digits after the decimal point", r.RatString(), scale)
+	}
+	unscaled := scaled.Num()
+	if digits := len(new(big.Int).Abs(unscaled).String()); digits > precision {
+		return nil, fmt.Errorf("decimal %s has %d digits which exceeds precision %d", r.RatString(), digits, precision)
+	}
+
+	var value []byte
+	if unscaled.Sign() >= 0 {
+		length := unscaled.BitLen()/8 + 1
+		value = padBytes(unscaled.Bytes(), length, 0)
+	} else {
+		length := new(big.Int).Sub(new(big.Int).Neg(unscaled), big.NewInt(1)).BitLen()/8 + 1
+		complement := new(big.Int).Lsh(big.NewInt(1), uint(8*length))
+		value = padBytes(complement.Add(complement, unscaled).Bytes(), length, 0)
+	}
+
+	if size >= 0 {
+		if len(value) > size {
+			return nil, fmt.Errorf("decimal %s doesn't fit into fixed of size %d", r.RatString(), size)
+		}
+		var sign byte
+		if unscaled.Sign() < 0 {
+			sign = 0xff
+		}
+		value = padBytes(value, size, sign)
+	}
+	return value, nil
+}
+
+// padBytes prepends pad bytes to value up to the given length.
+func padBytes(value []byte, length int, pad byte) []byte {
+	if len(value) >= length {
+		return value
+	}
+	padded := make([]byte, length)
+	for i := 0; i < length-len(value); i++ {
+		padded[i] = pad
+	}
+	copy(padded[length-len(value):], value)
+	return padded
+}
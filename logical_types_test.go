@@ -0,0 +1,373 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const logicalTimeSchemaRaw = `{"type": "record", "name": "Event", "fields": [
+	{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+	{"name": "timeOfDay", "type": {"type": "int", "logicalType": "time-millis"}},
+	{"name": "timeOfDayMicros", "type": {"type": "long", "logicalType": "time-micros"}},
+	{"name": "createdAt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "updatedAt", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+	{"name": "localAt", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+	{"name": "deletedAt", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}], "default": null}
+]}`
+
+type logicalTimeEvent struct {
+	Day             time.Time     `avro:"day"`
+	TimeOfDay       time.Duration `avro:"timeOfDay"`
+	TimeOfDayMicros time.Duration `avro:"timeOfDayMicros"`
+	CreatedAt       time.Time     `avro:"createdAt"`
+	UpdatedAt       time.Time     `avro:"updatedAt"`
+	LocalAt         time.Time     `avro:"localAt"`
+	DeletedAt       *time.Time    `avro:"deletedAt"`
+}
+
+func TestLogicalTypeSchemaRoundTrip(t *testing.T) {
+	schema := MustParseSchema(logicalTimeSchemaRaw).(*RecordSchema)
+	assert(t, schema.Fields[0].Type.(*IntSchema).LogicalType, LogicalTypeDate)
+	assert(t, schema.Fields[4].Type.(*LongSchema).LogicalType, LogicalTypeTimestampMicros)
+
+	encoded, err := json.Marshal(schema.Fields[3].Type)
+	assert(t, err, nil)
+	assert(t, string(encoded), `{"type":"long","logicalType":"timestamp-millis"}`)
+
+	reparsed := MustParseSchema(schema.String()).(*RecordSchema)
+	for i, field := range schema.Fields {
+		assert(t, reparsed.Fields[i].Type.String(), field.Type.String())
+	}
+
+	// unknown logical types are kept but don't change the mapping
+	custom := MustParseSchema(`{"type": "long", "logicalType": "custom"}`)
+	assert(t, custom.String(), `{"type": "long", "logicalType": "custom"}`)
+	assert(t, isLogical(custom), false)
+}
+
+func TestLogicalTypeSpecific(t *testing.T) {
+	schema := MustParseSchema(logicalTimeSchemaRaw)
+	deleted := time.Date(2019, 3, 4, 5, 6, 7, 8000000, time.UTC)
+	in := &logicalTimeEvent{
+		Day:             time.Date(1969, 7, 20, 0, 0, 0, 0, time.UTC),
+		TimeOfDay:       13*time.Hour + 14*time.Minute + 15*time.Second + 16*time.Millisecond,
+		TimeOfDayMicros: 23*time.Hour + 17*time.Microsecond,
+		CreatedAt:       time.Date(2020, 2, 23, 10, 11, 12, 13000000, time.UTC),
+		UpdatedAt:       time.Date(1960, 1, 2, 3, 4, 5, 6000, time.UTC),
+		LocalAt:         time.Date(2020, 2, 23, 10, 11, 12, 0, time.FixedZone("AEDT", 11*60*60)),
+		DeletedAt:       &deleted,
+	}
+
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(in, NewBinaryEncoder(&buf)), nil)
+
+	for _, s := range []Schema{schema, Prepare(schema)} {
+		out := &logicalTimeEvent{}
+		assert(t, NewDatumReader(s).Read(out, NewBinaryDecoder(buf.Bytes())), nil)
+		assert(t, out.Day, in.Day)
+		assert(t, out.TimeOfDay, in.TimeOfDay)
+		assert(t, out.TimeOfDayMicros, in.TimeOfDayMicros)
+		assert(t, out.CreatedAt, in.CreatedAt)
+		assert(t, out.UpdatedAt, in.UpdatedAt)
+		assert(t, out.LocalAt, time.Date(2020, 2, 23, 10, 11, 12, 0, time.UTC))
+		assert(t, *out.DeletedAt, deleted)
+	}
+
+	// the epoch values are readable into plain numeric fields as well
+	var raw struct {
+		Day             int32  `avro:"day"`
+		TimeOfDay       int32  `avro:"timeOfDay"`
+		TimeOfDayMicros int64  `avro:"timeOfDayMicros"`
+		CreatedAt       int64  `avro:"createdAt"`
+		UpdatedAt       int64  `avro:"updatedAt"`
+		LocalAt         int64  `avro:"localAt"`
+		DeletedAt       *int64 `avro:"deletedAt"`
+	}
+	assert(t, NewDatumReader(schema).Read(&raw, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, raw.Day, int32(-165))
+	assert(t, raw.CreatedAt, in.CreatedAt.Unix()*1000+13)
+	assert(t, raw.LocalAt, time.Date(2020, 2, 23, 10, 11, 12, 0, time.UTC).Unix()*1000)
+	assert(t, *raw.DeletedAt, deleted.Unix()*1000+8)
+}
+
+func TestLogicalTypeGeneric(t *testing.T) {
+	schema := MustParseSchema(logicalTimeSchemaRaw)
+	createdAt := time.Date(2020, 2, 23, 10, 11, 12, 13000000, time.UTC)
+
+	in := NewGenericRecord(schema)
+	in.Set("day", time.Date(2020, 2, 23, 0, 0, 0, 0, time.UTC))
+	in.Set("timeOfDay", 5*time.Second)
+	in.Set("timeOfDayMicros", 5*time.Microsecond)
+	in.Set("createdAt", createdAt)
+	in.Set("updatedAt", createdAt.UnixNano()/1000)
+	in.Set("localAt", createdAt)
+	in.Set("deletedAt", nil)
+
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(in, NewBinaryEncoder(&buf)), nil)
+
+	out := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(out, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, out.Get("day"), time.Date(2020, 2, 23, 0, 0, 0, 0, time.UTC))
+	assert(t, out.Get("timeOfDay"), 5*time.Second)
+	assert(t, out.Get("timeOfDayMicros"), 5*time.Microsecond)
+	assert(t, out.Get("createdAt"), createdAt)
+	assert(t, out.Get("updatedAt"), createdAt)
+	assert(t, out.Get("localAt"), createdAt)
+	assert(t, out.Get("deletedAt"), nil)
+}
+
+func TestLogicalTypeProjection(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "createdAt", "type": "int"}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "createdAt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}, "default": 1}
+	]}`)
+
+	var buf bytes.Buffer
+	in := NewGenericRecord(writerSchema)
+	in.Set("createdAt", int32(1500))
+	assert(t, NewDatumWriter(writerSchema).Write(in, NewBinaryEncoder(&buf)), nil)
+
+	projector, err := NewDatumProjector(readerSchema, writerSchema)
+	assert(t, err, nil)
+
+	var out struct {
+		CreatedAt time.Time
+		Day       time.Time
+	}
+	assert(t, projector.Read(&out, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, out.CreatedAt, time.Unix(1, 500000000).UTC())
+	assert(t, out.Day, time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	var raw struct {
+		CreatedAt int64
+		Day       int32
+	}
+	assert(t, projector.Read(&raw, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, raw.CreatedAt, int64(1500))
+	assert(t, raw.Day, int32(1))
+
+	generic := NewGenericRecord(readerSchema)
+	assert(t, projector.Read(generic, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, generic.Get("createdAt"), time.Unix(1, 500000000).UTC())
+	assert(t, generic.Get("day"), time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC))
+}
+
+const logicalDecimalSchemaRaw = `{"type": "record", "name": "Payment", "fields": [
+	{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+	{"name": "rate", "type": {"type": "fixed", "name": "Rate", "size": 4, "logicalType": "decimal", "precision": 8, "scale": 4}},
+	{"name": "fee", "type": ["null", {"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}], "default": null}
+]}`
+
+type logicalDecimalPayment struct {
+	Amount *big.Rat `avro:"amount"`
+	Rate   big.Rat  `avro:"rate"`
+	Fee    *big.Rat `avro:"fee"`
+}
+
+func TestLogicalTypeDecimalSchema(t *testing.T) {
+	schema := MustParseSchema(logicalDecimalSchemaRaw).(*RecordSchema)
+	amount := schema.Fields[0].Type.(*BytesSchema)
+	assert(t, amount.LogicalType, LogicalTypeDecimal)
+	assert(t, amount.Precision, 9)
+	assert(t, amount.Scale, 2)
+	assert(t, amount.String(), `{"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}`)
+	assert(t, MustParseSchema(amount.String()), Schema(amount))
+
+	rate := schema.Fields[1].Type.(*FixedSchema)
+	assert(t, rate.Precision, 8)
+	assert(t, rate.Scale, 4)
+	assert(t, len(rate.Properties), 0)
+
+	reparsed := MustParseSchema(schema.String()).(*RecordSchema)
+	assert(t, reparsed.Fields[0].Type.String(), amount.String())
+	assert(t, reparsed.Fields[1].Type.(*FixedSchema).Precision, 8)
+
+	// precision must be positive, not smaller than the scale and fit into the fixed size
+	assert(t, isLogical(MustParseSchema(`{"type": "bytes", "logicalType": "decimal"}`)), false)
+	assert(t, isLogical(MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 2, "scale": 3}`)), false)
+	assert(t, isLogical(MustParseSchema(`{"type": "fixed", "name": "f", "size": 1, "logicalType": "decimal", "precision": 2}`)), true)
+	assert(t, isLogical(MustParseSchema(`{"type": "fixed", "name": "f", "size": 1, "logicalType": "decimal", "precision": 3}`)), false)
+	assert(t, maxDecimalPrecision(8), 18)
+	assert(t, maxDecimalPrecision(16), 38)
+}
+
+func TestLogicalTypeDecimalEncoding(t *testing.T) {
+	bytesSchema := MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 5, "scale": 2}`)
+	fixedSchema := MustParseSchema(`{"type": "fixed", "name": "f", "size": 3, "logicalType": "decimal", "precision": 5, "scale": 2}`)
+
+	for _, c := range []struct {
+		value string
+		bytes []byte
+		fixed []byte
+	}{
+		{"0", []byte{0x00}, []byte{0x00, 0x00, 0x00}},
+		{"1.27", []byte{0x7f}, []byte{0x00, 0x00, 0x7f}},
+		{"1.28", []byte{0x00, 0x80}, []byte{0x00, 0x00, 0x80}},
+		{"-1.28", []byte{0x80}, []byte{0xff, 0xff, 0x80}},
+		{"-1.29", []byte{0xff, 0x7f}, []byte{0xff, 0xff, 0x7f}},
+		{"-0.01", []byte{0xff}, []byte{0xff, 0xff, 0xff}},
+		{"999.99", []byte{0x01, 0x86, 0x9f}, []byte{0x01, 0x86, 0x9f}},
+	} {
+		r, _ := new(big.Rat).SetString(c.value)
+		encoded, ok, err := encodeLogical(bytesSchema, r)
+		assert(t, ok, true)
+		assert(t, err, nil)
+		assert(t, encoded, c.bytes)
+		encoded, _, err = encodeLogical(fixedSchema, r)
+		assert(t, err, nil)
+		assert(t, encoded, c.fixed)
+
+		for _, schema := range []Schema{bytesSchema, fixedSchema} {
+			encoded, _, _ := encodeLogical(schema, r)
+			decoded, err := decodeLogical(schema, encoded)
+			assert(t, err, nil)
+			assert(t, decoded.(*big.Rat).Cmp(r), 0)
+		}
+	}
+
+	for _, value := range []string{"0.001", "1000", "-1000"} {
+		r, _ := new(big.Rat).SetString(value)
+		_, _, err := encodeLogical(bytesSchema, r)
+		assert(t, err != nil, true)
+	}
+}
+
+func TestLogicalTypeDecimalSpecific(t *testing.T) {
+	schema := MustParseSchema(logicalDecimalSchemaRaw)
+	in := &logicalDecimalPayment{Amount: big.NewRat(-123456789, 100)}
+	in.Rate.SetString("0.0725")
+
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(in, NewBinaryEncoder(&buf)), nil)
+
+	out := &logicalDecimalPayment{}
+	assert(t, NewDatumReader(schema).Read(out, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, out.Amount.RatString(), "-123456789/100")
+	assert(t, out.Rate.RatString(), "29/400")
+	assert(t, out.Fee, (*big.Rat)(nil))
+
+	in.Fee = big.NewRat(100, 1)
+	assert(t, NewDatumWriter(schema).Write(in, NewBinaryEncoder(&buf)) != nil, true)
+
+	// the unscaled value is available as bytes
+	var raw struct {
+		Amount []byte `avro:"amount"`
+		Rate   []byte `avro:"rate"`
+		Fee    []byte `avro:"fee"`
+	}
+	assert(t, NewDatumReader(schema).Read(&raw, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, raw.Rate, []byte{0x00, 0x00, 0x02, 0xd5})
+}
+
+func TestLogicalTypeDecimalGeneric(t *testing.T) {
+	schema := MustParseSchema(logicalDecimalSchemaRaw)
+
+	in := NewGenericRecord(schema)
+	in.Set("amount", big.NewRat(5, 4))
+	in.Set("rate", big.NewRat(1, 2))
+	in.Set("fee", big.NewRat(1, 10))
+
+	var buf bytes.Buffer
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(in, NewBinaryEncoder(&buf)), nil)
+
+	out := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(out, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, out.Get("amount"), big.NewRat(5, 4))
+	assert(t, out.Get("rate"), big.NewRat(1, 2))
+	assert(t, out.Get("fee"), big.NewRat(1, 10))
+
+	converted, err := schema.(*RecordSchema).Fields[1].Type.Generic(0.5)
+	assert(t, err, nil)
+	assert(t, converted, big.NewRat(1, 2))
+
+	// floats convert to their decimal form, so they fit the scale
+	converted, err = schema.(*RecordSchema).Fields[0].Type.Generic(0.1)
+	assert(t, err, nil)
+	assert(t, converted, big.NewRat(1, 10))
+	in.Set("amount", converted)
+	buf.Reset()
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write(in, NewBinaryEncoder(&buf)), nil)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(out, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, out.Get("amount"), big.NewRat(1, 10))
+}
+
+const logicalIdentitySchemaRaw = `{"type": "record", "name": "Subscription", "fields": [
+	{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+	{"name": "owner", "type": ["null", {"type": "string", "logicalType": "uuid"}], "default": null},
+	{"name": "period", "type": {"type": "fixed", "name": "period", "size": 12, "logicalType": "duration"}}
+]}`
+
+type logicalSubscription struct {
+	ID     UUID     `avro:"id"`
+	Owner  *UUID    `avro:"owner"`
+	Period Duration `avro:"period"`
+}
+
+func TestLogicalTypeUUID(t *testing.T) {
+	u, err := ParseUUID("123e4567-e89b-12d3-a456-426655440000")
+	assert(t, err, nil)
+	assert(t, u, UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x55, 0x44, 0x00, 0x00})
+	assert(t, u.String(), "123e4567-e89b-12d3-a456-426655440000")
+
+	for _, invalid := range []string{"", "123e4567e89b12d3a456426655440000", "123e4567-e89b-12d3-a456-42665544000g",
+		"123e4567-e89b-12d3-a456_426655440000"} {
+		_, err := ParseUUID(invalid)
+		assert(t, err != nil, true)
+	}
+
+	schema := MustParseSchema(`{"type": "string", "logicalType": "uuid"}`)
+	assert(t, schema.String(), `{"type": "string", "logicalType": "uuid"}`)
+
+	// plain strings are validated when written
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write("123e4567-e89b-12d3-a456-426655440000", NewBinaryEncoder(&buf)), nil)
+	assert(t, NewDatumWriter(schema).Write("not a uuid", NewBinaryEncoder(&buf)) != nil, true)
+	assert(t, NewGenericDatumWriter().SetSchema(schema).Write("not a uuid", NewBinaryEncoder(&buf)) != nil, true)
+}
+
+func TestLogicalTypeUUIDAndDuration(t *testing.T) {
+	schema := MustParseSchema(logicalIdentitySchemaRaw)
+	owner := UUID{15: 1}
+	in := &logicalSubscription{
+		ID:     UUID{0: 0xff, 15: 0xee},
+		Owner:  &owner,
+		Period: Duration{Months: 1, Days: 2, Milliseconds: 3},
+	}
+
+	var buf bytes.Buffer
+	assert(t, NewDatumWriter(schema).Write(in, NewBinaryEncoder(&buf)), nil)
+	assert(t, buf.Bytes()[1:37], []byte("ff000000-0000-0000-0000-0000000000ee"))
+	assert(t, buf.Bytes()[len(buf.Bytes())-12:], []byte{1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0})
+
+	out := &logicalSubscription{}
+	assert(t, NewDatumReader(schema).Read(out, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, out.ID, in.ID)
+	assert(t, *out.Owner, owner)
+	assert(t, out.Period, in.Period)
+
+	generic := NewGenericRecord(schema)
+	assert(t, NewGenericDatumReader().SetSchema(schema).Read(generic, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, generic.Get("id"), in.ID)
+	assert(t, generic.Get("owner"), owner)
+	assert(t, generic.Get("period"), in.Period)
+
+	var raw struct {
+		ID     string  `avro:"id"`
+		Owner  *string `avro:"owner"`
+		Period []byte  `avro:"period"`
+	}
+	assert(t, NewDatumReader(schema).Read(&raw, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, raw.ID, "ff000000-0000-0000-0000-0000000000ee")
+	assert(t, *raw.Owner, "00000000-0000-0000-0000-000000000001")
+	assert(t, raw.Period, []byte{1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0})
+
+	// duration only applies to a fixed of size 12
+	assert(t, isLogical(MustParseSchema(`{"type": "fixed", "name": "f", "size": 8, "logicalType": "duration"}`)), false)
+}
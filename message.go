@@ -0,0 +1,134 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// MessageMagic is the marker every single-object encoded message starts with.
+var MessageMagic = []byte{0xC3, 0x01}
+
+// messageHeaderSize is the size of the marker and the fingerprint of the writer schema.
+const messageHeaderSize = 10
+
+// SchemaStore maps the CRC-64-AVRO fingerprints of schemas to the schemas themselves, to find the writer schema of a
+// single-object encoded message. It is safe for concurrent use.
+type SchemaStore struct {
+	mu      sync.RWMutex
+	schemas map[uint64]Schema
+}
+
+// NewSchemaStore creates a SchemaStore containing the given schemas.
+func NewSchemaStore(schemas ...Schema) *SchemaStore {
+	store := &SchemaStore{schemas: make(map[uint64]Schema)}
+	for _, schema := range schemas {
+		store.Add(schema)
+	}
+	return store
+}
+
+// Add adds a schema to the store and returns its fingerprint.
+func (s *SchemaStore) Add(schema Schema) uint64 {
+	fingerprint := RabinFingerprint(schema)
+	s.mu.Lock()
+	s.schemas[fingerprint] = schema
+	s.mu.Unlock()
+	return fingerprint
+}
+
+// Get returns the schema with the given fingerprint, if the store contains it.
+func (s *SchemaStore) Get(fingerprint uint64) (Schema, bool) {
+	s.mu.RLock()
+	schema, ok := s.schemas[fingerprint]
+	s.mu.RUnlock()
+	return schema, ok
+}
+
+// MessageEncoder writes datums in the single-object encoding: the marker, the fingerprint of the writer schema and
+// the binary encoded datum.
+// Spec: https://avro.apache.org/docs/current/spec.html#single_object_encoding
+type MessageEncoder struct {
+	header []byte
+	writer DatumWriter
+}
+
+// NewMessageEncoder creates a MessageEncoder that writes datums with the given schema.
+func NewMessageEncoder(schema Schema) *MessageEncoder {
+	header := make([]byte, messageHeaderSize)
+	copy(header, MessageMagic)
+	binary.LittleEndian.PutUint64(header[len(MessageMagic):], RabinFingerprint(schema))
+	return &MessageEncoder{header: header, writer: NewDatumWriter(schema)}
+}
+
+// Encode returns the single-object encoding of a Go struct or GenericRecord.
+func (e *MessageEncoder) Encode(datum interface{}) ([]byte, error) {
+	buf := bytes.NewBuffer(append([]byte(nil), e.header...))
+	if err := e.writer.Write(datum, NewBinaryEncoder(buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MessageDecoder reads single-object encoded messages. The writer schema of each message is looked up in a
+// SchemaStore by its fingerprint and resolved against the reader schema.
+type MessageDecoder struct {
+	readerSchema Schema
+	store        *SchemaStore
+
+	mu      sync.Mutex
+	readers map[uint64]DatumReader
+}
+
+// NewMessageDecoder creates a MessageDecoder that reads messages written with any schema in the store. If the reader
+// schema is nil, datums are read with the writer schema as they are.
+func NewMessageDecoder(readerSchema Schema, store *SchemaStore) *MessageDecoder {
+	return &MessageDecoder{
+		readerSchema: readerSchema,
+		store:        store,
+		readers:      make(map[uint64]DatumReader),
+	}
+}
+
+// MessageFingerprint returns the fingerprint of the writer schema of a single-object encoded message.
+func MessageFingerprint(message []byte) (uint64, error) {
+	if len(message) < messageHeaderSize || !bytes.HasPrefix(message, MessageMagic) {
+		return 0, ErrNotSingleObject
+	}
+	return binary.LittleEndian.Uint64(message[len(MessageMagic):]), nil
+}
+
+// Decode reads a single-object encoded message into target, a pointer to a Go struct or a GenericRecord.
+func (d *MessageDecoder) Decode(message []byte, target interface{}) error {
+	if fingerprint, err := MessageFingerprint(message); err != nil {
+		return err
+	} else if reader, err := d.reader(fingerprint); err != nil {
+		return err
+	} else {
+		return reader.Read(target, NewBinaryDecoder(message[messageHeaderSize:]))
+	}
+}
+
+// reader returns the cached datum reader for messages written with the schema with the given fingerprint.
+func (d *MessageDecoder) reader(fingerprint uint64) (DatumReader, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if reader, ok := d.readers[fingerprint]; ok {
+		return reader, nil
+	}
+	writerSchema, ok := d.store.Get(fingerprint)
+	if !ok {
+		return nil, fmt.Errorf("unknown writer schema fingerprint: %016x", fingerprint)
+	}
+	var reader DatumReader
+	if d.readerSchema == nil {
+		reader = NewDatumReader(writerSchema)
+	} else if projector, err := NewDatumProjector(d.readerSchema, writerSchema); err != nil {
+		return nil, err
+	} else {
+		reader = projector
+	}
+	d.readers[fingerprint] = reader
+	return reader, nil
+}
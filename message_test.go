@@ -0,0 +1,65 @@
+package avro
+
+import (
+	"testing"
+)
+
+func TestMessageEncoding(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "Ping", "fields": [
+		{"name": "seq", "type": "int"},
+		{"name": "source", "type": "string"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "Ping", "fields": [
+		{"name": "seq", "type": "long"},
+		{"name": "source", "type": "string"},
+		{"name": "ttl", "type": "int", "default": 64}
+	]}`)
+
+	type ping struct {
+		Seq    int32
+		Source string
+	}
+	message, err := NewMessageEncoder(v1).Encode(&ping{Seq: 3, Source: "a"})
+	assert(t, err, nil)
+	assert(t, message[:2], []byte{0xC3, 0x01})
+	fingerprint, err := MessageFingerprint(message)
+	assert(t, err, nil)
+	assert(t, fingerprint, RabinFingerprint(v1))
+	assert(t, message[2:10], []byte{
+		byte(fingerprint), byte(fingerprint >> 8), byte(fingerprint >> 16), byte(fingerprint >> 24),
+		byte(fingerprint >> 32), byte(fingerprint >> 40), byte(fingerprint >> 48), byte(fingerprint >> 56),
+	})
+	assert(t, message[10:], []byte{6, 2, 'a'})
+
+	store := NewSchemaStore(v1)
+
+	var projected struct {
+		Seq    int64
+		Source string
+		Ttl    int32
+	}
+	decoder := NewMessageDecoder(v2, store)
+	assert(t, decoder.Decode(message, &projected), nil)
+	assert(t, projected.Seq, int64(3))
+	assert(t, projected.Source, "a")
+	assert(t, projected.Ttl, int32(64))
+
+	var unprojected ping
+	assert(t, NewMessageDecoder(nil, store).Decode(message, &unprojected), nil)
+	assert(t, unprojected, ping{Seq: 3, Source: "a"})
+
+	record := NewGenericRecord(v2)
+	record.Set("seq", int64(4))
+	record.Set("source", "b")
+	record.Set("ttl", int32(1))
+	message, err = NewMessageEncoder(v2).Encode(record)
+	assert(t, err, nil)
+	assert(t, decoder.Decode(message, &projected) != nil, true)
+	assert(t, store.Add(v2), RabinFingerprint(v2))
+	assert(t, decoder.Decode(message, &projected), nil)
+	assert(t, projected.Seq, int64(4))
+	assert(t, projected.Ttl, int32(1))
+
+	assert(t, decoder.Decode([]byte{0xC3, 0x01, 0}, &projected), ErrNotSingleObject)
+	assert(t, decoder.Decode(append([]byte{0, 0}, message[2:]...), &projected), ErrNotSingleObject)
+}
@@ -96,17 +96,20 @@ const (
 )
 
 const (
-	schemaAliasesField   = "aliases"
-	schemaDefaultField   = "default"
-	schemaDocField       = "doc"
-	schemaFieldsField    = "fields"
-	schemaItemsField     = "items"
-	schemaNameField      = "name"
-	schemaNamespaceField = "namespace"
-	schemaSizeField      = "size"
-	schemaSymbolsField   = "symbols"
-	schemaTypeField      = "type"
-	schemaValuesField    = "values"
+	schemaAliasesField     = "aliases"
+	schemaDefaultField     = "default"
+	schemaDocField         = "doc"
+	schemaFieldsField      = "fields"
+	schemaItemsField       = "items"
+	schemaLogicalTypeField = "logicalType"
+	schemaNameField        = "name"
+	schemaNamespaceField   = "namespace"
+	schemaPrecisionField   = "precision"
+	schemaScaleField       = "scale"
+	schemaSizeField        = "size"
+	schemaSymbolsField     = "symbols"
+	schemaTypeField        = "type"
+	schemaValuesField      = "values"
 )
 
 // Schema is an interface representing a single Avro schema (both primitive and complex).
@@ -140,7 +143,10 @@ type Schema interface {
 }
 
 // StringSchema implements Schema and represents Avro string type.
-type StringSchema struct{}
+type StringSchema struct {
+	// LogicalType is the optional logical type annotation, e.g. "uuid".
+	LogicalType string
+}
 
 // Returns representation considering whether the same type was already declared
 func (s *StringSchema) withRegistry(registry map[string]Schema) Schema {
@@ -156,12 +162,18 @@ func (*StringSchema) Fingerprint() (*Fingerprint, error) {
 }
 
 // Returns a JSON representation of StringSchema.
-func (*StringSchema) String() string {
+func (s *StringSchema) String() string {
+	if s.LogicalType != "" {
+		return fmt.Sprintf(`{"type": "string", "logicalType": %q}`, s.LogicalType)
+	}
 	return `{"type": "string"}`
 }
 
 // Converts go runtime datum into a value acceptable by this schema
-func (*StringSchema) Generic(datum interface{}) (interface{}, error) {
+func (s *StringSchema) Generic(datum interface{}) (interface{}, error) {
+	if isLogical(s) {
+		return genericLogical(s, datum)
+	}
 	if value, ok := datum.(string); ok {
 		return value, nil
 	} else if value, ok := datum.(fmt.Stringer); ok {
@@ -187,7 +199,10 @@ func (*StringSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*StringSchema) Validate(v reflect.Value) bool {
+func (s *StringSchema) Validate(v reflect.Value) bool {
+	if isLogical(s) && validateLogical(s, v) {
+		return true
+	}
 	_, ok := dereference(v).Interface().(string)
 	return ok
 }
@@ -198,12 +213,27 @@ func (s *StringSchema) Canonical() (*CanonicalSchema, error) {
 }
 
 // Standard JSON representation
-func (*StringSchema) MarshalJSON() ([]byte, error) {
+func (s *StringSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != "" {
+		return json.Marshal(struct {
+			Type        string `json:"type"`
+			LogicalType string `json:"logicalType"`
+		}{
+			Type:        typeString,
+			LogicalType: s.LogicalType,
+		})
+	}
 	return []byte(`"string"`), nil
 }
 
 // BytesSchema implements Schema and represents Avro bytes type.
-type BytesSchema struct{}
+type BytesSchema struct {
+	// LogicalType is the optional logical type annotation, e.g. "decimal".
+	LogicalType string
+	// Precision and Scale are the attributes of the decimal logical type.
+	Precision int
+	Scale     int
+}
 
 // Returns a pre-computed or cached fingerprint
 func (*BytesSchema) Fingerprint() (*Fingerprint, error) {
@@ -219,12 +249,25 @@ func (s *BytesSchema) withRegistry(registry map[string]Schema) Schema {
 }
 
 // String returns a JSON representation of BytesSchema.
-func (*BytesSchema) String() string {
+func (s *BytesSchema) String() string {
+	if s.LogicalType != "" {
+		attributes := ""
+		if s.Precision != 0 {
+			attributes += fmt.Sprintf(`, "precision": %d`, s.Precision)
+		}
+		if s.Scale != 0 {
+			attributes += fmt.Sprintf(`, "scale": %d`, s.Scale)
+		}
+		return fmt.Sprintf(`{"type": "bytes", "logicalType": %q%s}`, s.LogicalType, attributes)
+	}
 	return `{"type": "bytes"}`
 }
 
 // Converts go runtime datum into a value acceptable by this schema
 func (s *BytesSchema) Generic(datum interface{}) (interface{}, error) {
+	if isLogical(s) {
+		return genericLogical(s, datum)
+	}
 	if value, ok := datum.([]byte); ok {
 		return value, nil
 	} else if value, ok := datum.(string); ok {
@@ -252,7 +295,10 @@ func (*BytesSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*BytesSchema) Validate(v reflect.Value) bool {
+func (s *BytesSchema) Validate(v reflect.Value) bool {
+	if isLogical(s) && validateLogical(s, v) {
+		return true
+	}
 	v = dereference(v)
 
 	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
@@ -264,12 +310,28 @@ func (s *BytesSchema) Canonical() (*CanonicalSchema, error) {
 }
 
 // Standard JSON representation
-func (*BytesSchema) MarshalJSON() ([]byte, error) {
+func (s *BytesSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != "" {
+		return json.Marshal(struct {
+			Type        string `json:"type"`
+			LogicalType string `json:"logicalType"`
+			Precision   int    `json:"precision,omitempty"`
+			Scale       int    `json:"scale,omitempty"`
+		}{
+			Type:        typeBytes,
+			LogicalType: s.LogicalType,
+			Precision:   s.Precision,
+			Scale:       s.Scale,
+		})
+	}
 	return []byte(`"bytes"`), nil
 }
 
 // IntSchema implements Schema and represents Avro int type.
-type IntSchema struct{}
+type IntSchema struct {
+	// LogicalType is the optional logical type annotation, e.g. "date" or "time-millis".
+	LogicalType string
+}
 
 // Returns representation considering whether the same type was already declared
 func (s *IntSchema) withRegistry(registry map[string]Schema) Schema {
@@ -285,12 +347,18 @@ func (*IntSchema) Fingerprint() (*Fingerprint, error) {
 }
 
 // String returns a JSON representation of IntSchema.
-func (*IntSchema) String() string {
+func (s *IntSchema) String() string {
+	if s.LogicalType != "" {
+		return fmt.Sprintf(`{"type": "int", "logicalType": %q}`, s.LogicalType)
+	}
 	return `{"type": "int"}`
 }
 
 // Converts go runtime datum into a value acceptable by this schema
 func (s *IntSchema) Generic(datum interface{}) (interface{}, error) {
+	if isLogical(s) {
+		return genericLogical(s, datum)
+	}
 	if value, ok := datum.(int32); ok {
 		return int32(value), nil
 	} else if value, ok := datum.(int); ok {
@@ -334,7 +402,10 @@ func (*IntSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*IntSchema) Validate(v reflect.Value) bool {
+func (s *IntSchema) Validate(v reflect.Value) bool {
+	if isLogical(s) && validateLogical(s, v) {
+		return true
+	}
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int32
 }
 
@@ -344,12 +415,24 @@ func (s *IntSchema) Canonical() (*CanonicalSchema, error) {
 }
 
 // Standard JSON representation
-func (*IntSchema) MarshalJSON() ([]byte, error) {
+func (s *IntSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != "" {
+		return json.Marshal(struct {
+			Type        string `json:"type"`
+			LogicalType string `json:"logicalType"`
+		}{
+			Type:        typeInt,
+			LogicalType: s.LogicalType,
+		})
+	}
 	return []byte(`"int"`), nil
 }
 
 // LongSchema implements Schema and represents Avro long type.
-type LongSchema struct{}
+type LongSchema struct {
+	// LogicalType is the optional logical type annotation, e.g. "timestamp-millis" or "time-micros".
+	LogicalType string
+}
 
 // Returns representation considering whether the same type was already declared
 func (s *LongSchema) withRegistry(registry map[string]Schema) Schema {
@@ -365,12 +448,18 @@ func (*LongSchema) Fingerprint() (*Fingerprint, error) {
 }
 
 // Returns a JSON representation of LongSchema.
-func (*LongSchema) String() string {
+func (s *LongSchema) String() string {
+	if s.LogicalType != "" {
+		return fmt.Sprintf(`{"type": "long", "logicalType": %q}`, s.LogicalType)
+	}
 	return `{"type": "long"}`
 }
 
 // Converts go runtime datum into a value acceptable by this schema
 func (s *LongSchema) Generic(datum interface{}) (interface{}, error) {
+	if isLogical(s) {
+		return genericLogical(s, datum)
+	}
 	if value, ok := datum.(int64); ok {
 		return int64(value), nil
 	} else if value, ok := datum.(float64); ok {
@@ -422,7 +511,10 @@ func (*LongSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*LongSchema) Validate(v reflect.Value) bool {
+func (s *LongSchema) Validate(v reflect.Value) bool {
+	if isLogical(s) && validateLogical(s, v) {
+		return true
+	}
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int64
 }
 
@@ -432,7 +524,16 @@ func (s *LongSchema) Canonical() (*CanonicalSchema, error) {
 }
 
 // Standard JSON representation
-func (*LongSchema) MarshalJSON() ([]byte, error) {
+func (s *LongSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != "" {
+		return json.Marshal(struct {
+			Type        string `json:"type"`
+			LogicalType string `json:"logicalType"`
+		}{
+			Type:        typeLong,
+			LogicalType: s.LogicalType,
+		})
+	}
 	return []byte(`"long"`), nil
 }
 
@@ -1063,6 +1164,9 @@ type SchemaField struct {
 	Type       Schema      `json:"type,omitempty"`
 	Aliases    []string    `json:"aliases,omitempty"`
 	Properties map[string]interface{}
+	// HasDefault tells a default of null from no default. It is set by ParseSchema, fields with a non-nil Default
+	// have a default either way.
+	HasDefault bool `json:"-"`
 }
 
 // Returns representation considering whether the same type was already declared
@@ -1071,12 +1175,18 @@ func (s *SchemaField) withRegistry(registry map[string]Schema) *SchemaField {
 		Name:       s.Name,
 		Aliases:    s.Aliases,
 		Default:    s.Default,
+		HasDefault: s.HasDefault,
 		Doc:        s.Doc,
 		Properties: s.Properties,
 		Type:       s.Type.withRegistry(registry),
 	}
 }
 
+// hasDefault checks whether the field has a default value, which may be null.
+func (s *SchemaField) hasDefault() bool {
+	return s.HasDefault || s.Default != nil
+}
+
 // Gets a custom non-reserved property from this schemafield and a bool representing if it exists.
 func (s *SchemaField) Prop(key string) (interface{}, bool) {
 	if s.Properties != nil {
@@ -1332,7 +1442,12 @@ func (s *ArraySchema) String() string {
 // Converts go runtime datum into a value acceptable by this schema
 func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 	if a, ok := datum.([]interface{}); ok {
-		if s.Items.Type() == String {
+		itemType := s.Items.Type()
+		if isLogical(s.Items) {
+			// native values of logical types are collected into an []interface{}
+			itemType = -1
+		}
+		if itemType == String {
 			slice := make([]string, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1342,7 +1457,7 @@ func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 				}
 			}
 			return slice, nil
-		} else if s.Items.Type() == Double {
+		} else if itemType == Double {
 			slice := make([]float64, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1352,7 +1467,7 @@ func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 				}
 			}
 			return slice, nil
-		} else if s.Items.Type() == Float {
+		} else if itemType == Float {
 			slice := make([]float32, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1362,7 +1477,7 @@ func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 				}
 			}
 			return slice, nil
-		} else if s.Items.Type() == Long {
+		} else if itemType == Long {
 			slice := make([]int64, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1372,7 +1487,7 @@ func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 				}
 			}
 			return slice, nil
-		} else if s.Items.Type() == Int {
+		} else if itemType == Int {
 			slice := make([]int32, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1382,7 +1497,7 @@ func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 				}
 			}
 			return slice, nil
-		} else if s.Items.Type() == Boolean {
+		} else if itemType == Boolean {
 			slice := make([]bool, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1392,7 +1507,7 @@ func (s *ArraySchema) Generic(datum interface{}) (interface{}, error) {
 				}
 			}
 			return slice, nil
-		} else if s.Items.Type() == Bytes || s.Items.Type() == Fixed {
+		} else if itemType == Bytes || itemType == Fixed {
 			slice := make([][]byte, len(a))
 			for i, v := range a {
 				if val, err := s.Items.Generic(v); err != nil {
@@ -1711,6 +1826,9 @@ type FixedSchema struct {
 	Namespace   string                 `json:"namespace"`
 	Name        string                 `json:"name"`
 	Size        int                    `json:"size"`
+	LogicalType string                 `json:"logicalType,omitempty"`
+	Precision   int                    `json:"precision,omitempty"`
+	Scale       int                    `json:"scale,omitempty"`
 	Properties  map[string]interface{} `json:"properties,omitempty"`
 	fingerprint *Fingerprint
 }
@@ -1750,6 +1868,9 @@ func (s *FixedSchema) String() string {
 
 // Converts go runtime datum into a value acceptable by this schema
 func (s *FixedSchema) Generic(datum interface{}) (interface{}, error) {
+	if isLogical(s) {
+		return genericLogical(s, datum)
+	}
 	if slice, ok := datum.([]byte); ok && len(slice) == s.Size {
 		return slice, nil
 	} else if plain, ok := datum.(string); ok && len(plain) == s.Size {
@@ -1781,6 +1902,9 @@ func (s *FixedSchema) Prop(key string) (interface{}, bool) {
 
 // Validate checks whether the given value is writeable to this schema.
 func (s *FixedSchema) Validate(v reflect.Value) bool {
+	if isLogical(s) && validateLogical(s, v) {
+		return true
+	}
 	v = dereference(v)
 
 	return (v.Kind() == reflect.Array || v.Kind() == reflect.Slice) && v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == s.Size
@@ -1798,17 +1922,23 @@ func (s *FixedSchema) Canonical() (*CanonicalSchema, error) {
 // MarshalJSON serializes the given schema as JSON.
 func (s *FixedSchema) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Type       string                 `json:"type,omitempty"`
-		Size       int                    `json:"size,omitempty"`
-		Name       string                 `json:"name,omitempty"`
-		Namespace  string                 `json:"namespace"`
-		Properties map[string]interface{} `json:"properties,omitempty"`
+		Type        string                 `json:"type,omitempty"`
+		Size        int                    `json:"size,omitempty"`
+		Name        string                 `json:"name,omitempty"`
+		Namespace   string                 `json:"namespace"`
+		LogicalType string                 `json:"logicalType,omitempty"`
+		Precision   int                    `json:"precision,omitempty"`
+		Scale       int                    `json:"scale,omitempty"`
+		Properties  map[string]interface{} `json:"properties,omitempty"`
 	}{
-		Type:       "fixed",
-		Size:       s.Size,
-		Name:       s.Name,
-		Namespace:  s.Namespace,
-		Properties: s.Properties,
+		Type:        "fixed",
+		Size:        s.Size,
+		Name:        s.Name,
+		Namespace:   s.Namespace,
+		LogicalType: s.LogicalType,
+		Precision:   s.Precision,
+		Scale:       s.Scale,
+		Properties:  s.Properties,
 	})
 }
 
@@ -1911,17 +2041,27 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 		case typeBoolean:
 			return new(BooleanSchema), nil
 		case typeInt:
-			return new(IntSchema), nil
+			schema := new(IntSchema)
+			setOptionalField(&schema.LogicalType, v, schemaLogicalTypeField)
+			return schema, nil
 		case typeLong:
-			return new(LongSchema), nil
+			schema := new(LongSchema)
+			setOptionalField(&schema.LogicalType, v, schemaLogicalTypeField)
+			return schema, nil
 		case typeFloat:
 			return new(FloatSchema), nil
 		case typeDouble:
 			return new(DoubleSchema), nil
 		case typeBytes:
-			return new(BytesSchema), nil
+			schema := new(BytesSchema)
+			setOptionalField(&schema.LogicalType, v, schemaLogicalTypeField)
+			setOptionalIntField(&schema.Precision, v, schemaPrecisionField)
+			setOptionalIntField(&schema.Scale, v, schemaScaleField)
+			return schema, nil
 		case typeString:
-			return new(StringSchema), nil
+			schema := new(StringSchema)
+			setOptionalField(&schema.LogicalType, v, schemaLogicalTypeField)
+			return schema, nil
 		case typeArray:
 			items, err := schemaByType(v[schemaItemsField], registry, namespace)
 			if err != nil {
@@ -1961,6 +2101,7 @@ func parseEnumSchema(v map[string]interface{}, registry map[string]Schema, names
 	schema := &EnumSchema{Name: v[schemaNameField].(string), Symbols: symbols}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
 	setOptionalField(&schema.Doc, v, schemaDocField)
+	setOptionalField(&namespace, v, schemaNamespaceField)
 	schema.Properties = getProperties(v)
 
 	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
@@ -1974,6 +2115,13 @@ func parseFixedSchema(v map[string]interface{}, registry map[string]Schema, name
 
 	schema := &FixedSchema{Name: v[schemaNameField].(string), Size: int(size), Properties: getProperties(v)}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
+	setOptionalField(&schema.LogicalType, v, schemaLogicalTypeField)
+	setOptionalIntField(&schema.Precision, v, schemaPrecisionField)
+	setOptionalIntField(&schema.Scale, v, schemaScaleField)
+	for _, name := range []string{schemaLogicalTypeField, schemaPrecisionField, schemaScaleField} {
+		delete(schema.Properties, name)
+	}
+	setOptionalField(&namespace, v, schemaNamespaceField)
 	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
 }
 
@@ -2029,6 +2177,7 @@ func parseSchemaField(i interface{}, registry map[string]Schema, namespace strin
 		}
 		schemaField.Type = fieldType
 		if def, exists := v[schemaDefaultField]; exists {
+			schemaField.HasDefault = true
 			switch def.(type) {
 			case float64:
 				// JSON treats all numbers as float64 by default
@@ -2062,6 +2211,12 @@ func setOptionalField(where *string, v map[string]interface{}, fieldName string)
 	}
 }
 
+func setOptionalIntField(where *int, v map[string]interface{}, fieldName string) {
+	if field, ok := v[fieldName].(float64); ok {
+		*where = int(field)
+	}
+}
+
 func addSchema(name string, schema Schema, schemas map[string]Schema) Schema {
 	if schemas != nil {
 		if sch, ok := schemas[name]; ok {
@@ -2179,7 +2334,7 @@ type CanonicalSchema struct {
 	Items   *CanonicalSchema        `json:"items,omitempty"`
 	Values  *CanonicalSchema        `json:"values,omitempty"`
 	Size    int                     `json:"size,omitempty"`
-	Types   []*CanonicalSchema      `json:"size,omit"`
+	Types   []*CanonicalSchema      `json:"types,omitempty"`
 }
 
 type CanonicalSchemaField struct {
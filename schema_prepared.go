@@ -81,10 +81,11 @@ func (job *prepareJob) prepareRecordSchema(input *RecordSchema) *preparedRecordS
 	output.Fields = nil
 	for _, field := range input.Fields {
 		output.Fields = append(output.Fields, &SchemaField{
-			Name:    field.Name,
-			Doc:     field.Doc,
-			Default: field.Default,
-			Type:    job.prepare(field.Type),
+			Name:       field.Name,
+			Doc:        field.Doc,
+			Default:    field.Default,
+			HasDefault: field.HasDefault,
+			Type:       job.prepare(field.Type),
 		})
 	}
 	return output
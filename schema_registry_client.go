@@ -10,6 +10,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -21,10 +22,25 @@ import (
 //SchemaRegistryClient is not concurrent //TODO maybe if both caches were sync.Map{} then it would become safe
 
 type SchemaRegistryClient struct {
-	Url    string
-	Tls    *tls.Config
-	cache1 map[uint32]Schema
-	cache2 map[string]map[Fingerprint]uint32
+	Url string
+	Tls *tls.Config
+	// AutoRegister makes Encode register schemas which aren't registered under the subject yet, otherwise Encode only
+	// looks them up, which works with read-only credentials.
+	AutoRegister bool
+	// UseLatestVersion makes Encode write datums with the latest schema registered under the subject instead of the
+	// given one. The latest version is looked up again after latestVersionTTL.
+	UseLatestVersion bool
+	cache1           map[uint32]Schema
+	cache2           map[string]map[Fingerprint]uint32
+	latest           map[string]*registeredSchema
+}
+
+const latestVersionTTL = 5 * time.Minute
+
+type registeredSchema struct {
+	id      uint32
+	schema  Schema
+	fetched time.Time
 }
 
 type schemaResponse struct {
@@ -116,6 +132,127 @@ func (c *SchemaRegistryClient) GetSchemaId(schema Schema, subject string) (uint3
 
 }
 
+// Encode writes a datum in the wire format of the Confluent Schema Registry: a zero byte, the big-endian schema id
+// and the binary encoded datum. Depending on AutoRegister and UseLatestVersion the schema is registered under the
+// subject, looked up, or replaced by the latest version registered under the subject.
+func (c *SchemaRegistryClient) Encode(subject string, schema Schema, datum interface{}) ([]byte, error) {
+	var schemaId uint32
+	if c.UseLatestVersion {
+		if latest, err := c.latestVersion(subject); err != nil {
+			return nil, err
+		} else {
+			schemaId, schema = latest.id, latest.schema
+		}
+	} else if schema == nil {
+		return nil, fmt.Errorf("a schema is required unless the latest version is used")
+	} else if c.AutoRegister {
+		if id, err := c.GetSchemaId(schema, subject); err != nil {
+			return nil, err
+		} else {
+			schemaId = id
+		}
+	} else if id, err := c.lookupSchemaId(schema, subject); err != nil {
+		return nil, err
+	} else {
+		schemaId = id
+	}
+
+	buf := bytes.NewBuffer([]byte{0, 0, 0, 0, 0})
+	binary.BigEndian.PutUint32(buf.Bytes()[1:], schemaId)
+	if err := NewDatumWriter(schema).Write(datum, NewBinaryEncoder(buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupSchemaId finds the id of a schema registered under the subject without registering it.
+func (c *SchemaRegistryClient) lookupSchemaId(schema Schema, subject string) (uint32, error) {
+	if c.cache2 == nil {
+		c.cache2 = make(map[string]map[Fingerprint]uint32)
+	}
+	var s map[Fingerprint]uint32
+	if s = c.cache2[subject]; s == nil {
+		s = make(map[Fingerprint]uint32)
+		c.cache2[subject] = s
+	}
+
+	f, err := schema.Fingerprint()
+	if err != nil {
+		return 0, err
+	}
+	if result, ok := s[*f]; ok {
+		return result, nil
+	}
+	response := new(subjectSchemaResponse)
+	if err := c.call("POST", "/subjects/"+subject, map[string]string{"schema": schema.String()}, response); err != nil {
+		return 0, fmt.Errorf("looking up schema %v under subject %q: %v", schema.GetName(), subject, err)
+	}
+	s[*f] = response.Id
+	return response.Id, nil
+}
+
+func (c *SchemaRegistryClient) latestVersion(subject string) (*registeredSchema, error) {
+	if c.latest == nil {
+		c.latest = make(map[string]*registeredSchema)
+	}
+	if latest := c.latest[subject]; latest != nil && time.Since(latest.fetched) < latestVersionTTL {
+		return latest, nil
+	}
+	response := new(subjectSchemaResponse)
+	if err := c.call("GET", "/subjects/"+subject+"/versions/latest", nil, response); err != nil {
+		return nil, fmt.Errorf("getting the latest version of subject %q: %v", subject, err)
+	}
+	schema, err := ParseSchema(response.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache1 == nil {
+		c.cache1 = make(map[uint32]Schema)
+	}
+	c.cache1[response.Id] = schema
+	latest := &registeredSchema{id: response.Id, schema: schema, fetched: time.Now()}
+	c.latest[subject] = latest
+	return latest, nil
+}
+
+type subjectSchemaResponse struct {
+	Id      uint32
+	Version int
+	Schema  string
+}
+
+// call sends a request to the registry and decodes the JSON response.
+func (c *SchemaRegistryClient) call(method, path string, request interface{}, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		if data, err := json.Marshal(request); err != nil {
+			return err
+		} else {
+			body = bytes.NewReader(data)
+		}
+	}
+	httpClient, err := c.getHttpClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequest(method, c.Url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("unexpected response from the schema registry: %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
 func (c *SchemaRegistryClient) getHttpClient() (*http.Client, error) {
 	transport := new(http.Transport)
 	transport.TLSClientConfig = c.Tls
@@ -0,0 +1,69 @@
+package avro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSchemaRegistryClientEncode(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "int", "default": 0}
+	]}`)
+
+	var requests []string
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var request struct{ Schema string }
+		json.NewDecoder(r.Body).Decode(&request)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/subjects/users-value/versions":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 7})
+		case r.Method == "POST" && r.URL.Path == "/subjects/users-value" && request.Schema == v1.String():
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 5, "version": 1, "schema": v1.String()})
+		case r.Method == "GET" && r.URL.Path == "/subjects/users-value/versions/latest":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 6, "version": 2, "schema": v2.String()})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+
+	type user struct {
+		Name string
+		Age  int32
+	}
+	datum := &user{Name: "a", Age: 3}
+
+	client := &SchemaRegistryClient{Url: registry.URL}
+	message, err := client.Encode("users-value", v1, datum)
+	assert(t, err, nil)
+	assert(t, message, []byte{0, 0, 0, 0, 5, 2, 'a'})
+	_, err = client.Encode("users-value", v2, datum)
+	assert(t, err != nil, true)
+	_, err = client.Encode("users-value", v1, datum)
+	assert(t, err, nil)
+	assert(t, requests, []string{"POST /subjects/users-value", "POST /subjects/users-value"})
+
+	client = &SchemaRegistryClient{Url: registry.URL, AutoRegister: true}
+	message, err = client.Encode("users-value", v2, datum)
+	assert(t, err, nil)
+	assert(t, message, []byte{0, 0, 0, 0, 7, 2, 'a', 6})
+
+	requests = nil
+	client = &SchemaRegistryClient{Url: registry.URL, UseLatestVersion: true}
+	message, err = client.Encode("users-value", nil, datum)
+	assert(t, err, nil)
+	assert(t, message, []byte{0, 0, 0, 0, 6, 2, 'a', 6})
+	_, err = client.Encode("users-value", v1, datum)
+	assert(t, err, nil)
+	assert(t, requests, []string{"GET /subjects/users-value/versions/latest"})
+
+	var decoded user
+	_, err = client.Decode(message, &decoded, nil)
+	assert(t, err, nil)
+	assert(t, decoded, *datum)
+}